@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// RolePermission 是 GormPolicy 使用的表，TenantID 是 FormatID 格式化后的租户 ID，为空的记录对所有租户生效
+type RolePermission struct {
+	ID         int64  `gorm:"primaryKey"`
+	TenantID   string `gorm:"size:64;not null;default:'';uniqueIndex:idx_role_permissions,priority:1"`
+	Role       string `gorm:"size:64;not null;uniqueIndex:idx_role_permissions,priority:2"`
+	Permission string `gorm:"size:128;not null;uniqueIndex:idx_role_permissions,priority:3"`
+}
+
+func NewGormPolicy(db *gorm.DB) PolicyProvider {
+	return &gormPolicy{db: db}
+}
+
+type gormPolicy struct {
+	db *gorm.DB
+}
+
+// Permissions implements PolicyProvider.
+func (p *gormPolicy) Permissions(ctx context.Context, tenantID any, roles []string) ([]string, error) {
+	var perms []string
+	err := p.db.WithContext(ctx).
+		Model(&RolePermission{}).
+		Distinct("permission").
+		Where("role IN ? AND tenant_id IN ?", roles, []string{"", FormatID(tenantID)}).
+		Pluck("permission", &perms).Error
+	return perms, err
+}
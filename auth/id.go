@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// ID 是用户 ID 和租户 ID 支持的类型，uuid.UUID 的底层类型是 [16]byte
+type ID interface {
+	~int | ~int64 | ~string | ~[16]byte
+}
+
+// FormatID 把 UserIDValue、TenantIDValue 返回的 ID 格式化为字符串，[16]byte 按 UUID 格式输出，nil 返回空字符串
+func FormatID(id any) string {
+	switch v := id.(type) {
+	case nil:
+		return ""
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case string:
+		return v
+	case [16]byte:
+		return uuid.UUID(v).String()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
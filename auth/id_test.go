@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/irvingos/go-tools/ctxkey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserID(t *testing.T) {
+	ctx := WithUserID(context.Background(), 1)
+	assert.Equal(t, 1, UserIDFrom(ctx))
+
+	userID := uuid.New()
+	ctx = WithUserID(context.Background(), userID)
+	retrieved, ok := UserIDAs[uuid.UUID](ctx)
+	assert.True(t, ok)
+	assert.Equal(t, userID, retrieved)
+
+	// 類型不一致時返回零值
+	assert.Equal(t, 0, UserIDFrom(ctx))
+	_, ok = UserIDAs[string](ctx)
+	assert.False(t, ok)
+}
+
+func TestTenantID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+
+	WithTenantID(c, "acme")
+	tenantID, ok := TenantIDAs[string](c)
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenantID)
+	assert.Equal(t, 0, TenantIDFrom(c))
+
+	WithTenantID(c, int64(7))
+	id64, ok := TenantIDAs[int64](c)
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), id64)
+}
+
+func TestDetach(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+	p := &Principal{UserID: 1, TenantID: 2, Username: "alice"}
+	WithPrincipal(c, p)
+
+	ctx := ctxkey.Detach(c)
+	assert.NotSame(t, c, ctx)
+	assert.Equal(t, 1, UserIDFrom(ctx))
+	assert.Equal(t, 2, TenantIDFrom(ctx))
+	assert.Equal(t, "alice", UsernameFrom(ctx))
+	retrieved, ok := PrincipalFrom(ctx)
+	assert.True(t, ok)
+	assert.Same(t, p, retrieved)
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/irvingos/go-tools/errorx"
+)
+
+// PolicyProvider 将角色解析为权限，tenantID 用于支持租户级别的授权，即 Principal.TenantID，没有租户时为 nil
+type PolicyProvider interface {
+	Permissions(ctx context.Context, tenantID any, roles []string) ([]string, error)
+}
+
+var policyProvider atomic.Pointer[PolicyProvider]
+
+// SetPolicyProvider 设置全局的 PolicyProvider，可以在运行时并发调用，p 为 nil 时只使用 Scopes
+func SetPolicyProvider(p PolicyProvider) {
+	if p == nil {
+		policyProvider.Store(nil)
+		return
+	}
+	policyProvider.Store(&p)
+}
+
+func currentPolicyProvider() PolicyProvider {
+	if p := policyProvider.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// Authorize 检查当前 principal 是否拥有全部 perms，没有 principal 时返回 errorx.ErrUnauthorized，
+// 权限不足时返回 errorx.ErrForbidden
+func Authorize(ctx context.Context, perms ...string) error {
+	p, ok := PrincipalFrom(ctx)
+	if !ok {
+		return errorx.ErrUnauthorized
+	}
+	granted, err := p.resolve(ctx, currentPolicyProvider())
+	if err != nil {
+		return err
+	}
+	for _, perm := range perms {
+		if !matchAny(p.Scopes, perm) && !matchAny(granted, perm) {
+			return errorx.ErrForbidden
+		}
+	}
+	return nil
+}
+
+func Can(ctx context.Context, perm string) bool {
+	return Authorize(ctx, perm) == nil
+}
+
+// matchAny 支持 "*" 匹配全部权限，"order:*" 匹配 "order:" 开头的权限
+func matchAny(granted []string, perm string) bool {
+	for _, g := range granted {
+		if g == perm || g == "*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(g, "*"); ok && strings.HasPrefix(perm, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// StaticPolicy 是角色到权限的静态映射，对所有租户相同
+type StaticPolicy map[string][]string
+
+// Permissions implements PolicyProvider.
+func (s StaticPolicy) Permissions(ctx context.Context, tenantID any, roles []string) ([]string, error) {
+	var perms []string
+	for _, role := range roles {
+		perms = append(perms, s[role]...)
+	}
+	return perms, nil
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/irvingos/go-tools/errorx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func usePolicy(t *testing.T, p PolicyProvider) {
+	SetPolicyProvider(p)
+	t.Cleanup(func() { SetPolicyProvider(nil) })
+}
+
+func TestWithPrincipal(t *testing.T) {
+	p := &Principal{UserID: 1, TenantID: 2, Username: "alice", Roles: []string{"admin"}}
+
+	ctx := WithPrincipal(context.Background(), p)
+	retrieved, ok := PrincipalFrom(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, p, retrieved)
+	assert.Equal(t, 1, UserIDFrom(ctx))
+	assert.Equal(t, 2, TenantIDFrom(ctx))
+	assert.Equal(t, "alice", UsernameFrom(ctx))
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+	WithPrincipal(c, p)
+	retrieved, ok = PrincipalFrom(c)
+	assert.True(t, ok)
+	assert.Equal(t, p, retrieved)
+	assert.Equal(t, 2, TenantIDFrom(c))
+}
+
+func TestCan_StaticPolicy(t *testing.T) {
+	usePolicy(t, StaticPolicy{
+		"admin":  {"*"},
+		"editor": {"article:*", "comment:delete"},
+		"viewer": {"article:read"},
+	})
+
+	ctx := context.Background()
+	viewer := WithPrincipal(ctx, &Principal{UserID: 1, Roles: []string{"viewer"}})
+	editor := WithPrincipal(ctx, &Principal{UserID: 2, Roles: []string{"viewer", "editor"}})
+	admin := WithPrincipal(ctx, &Principal{UserID: 3, Roles: []string{"admin"}})
+
+	assert.True(t, Can(viewer, "article:read"))
+	assert.False(t, Can(viewer, "article:write"))
+	assert.True(t, Can(editor, "article:write"))
+	assert.True(t, Can(editor, "comment:delete"))
+	assert.False(t, Can(editor, "comment:create"))
+	assert.True(t, Can(admin, "anything"))
+
+	assert.Equal(t, errorx.ErrForbidden, Authorize(editor, "article:read", "comment:create"))
+	assert.NoError(t, Authorize(editor, "article:read", "comment:delete"))
+
+	// 沒有 principal
+	assert.Equal(t, errorx.ErrUnauthorized, Authorize(ctx, "article:read"))
+	assert.False(t, Can(ctx, "article:read"))
+}
+
+func TestCan_Scopes(t *testing.T) {
+	usePolicy(t, StaticPolicy{})
+
+	ctx := WithPrincipal(context.Background(), &Principal{Scopes: []string{"report:read"}})
+	assert.True(t, Can(ctx, "report:read"))
+	assert.False(t, Can(ctx, "report:write"))
+}
+
+type countingPolicy struct {
+	calls int
+	err   error
+}
+
+func (p *countingPolicy) Permissions(ctx context.Context, tenantID any, roles []string) ([]string, error) {
+	p.calls++
+	return []string{"a"}, p.err
+}
+
+func TestAuthorize_ResolveOnce(t *testing.T) {
+	policy := &countingPolicy{}
+	usePolicy(t, policy)
+
+	ctx := WithPrincipal(context.Background(), &Principal{Roles: []string{"r"}})
+	assert.True(t, Can(ctx, "a"))
+	assert.False(t, Can(ctx, "b"))
+	assert.Equal(t, 1, policy.calls)
+
+	// 查詢權限失敗時返回原始錯誤，並且不快取錯誤，下次重新查詢
+	testErr := errors.New("test error")
+	failing := &countingPolicy{err: testErr}
+	usePolicy(t, failing)
+	ctx = WithPrincipal(context.Background(), &Principal{Roles: []string{"r"}})
+	assert.ErrorIs(t, Authorize(ctx, "a"), testErr)
+	failing.err = nil
+	assert.NoError(t, Authorize(ctx, "a"))
+	assert.NoError(t, Authorize(ctx, "a"))
+	assert.Equal(t, 2, failing.calls)
+}
+
+func TestSetPolicyProvider_Concurrent(t *testing.T) {
+	t.Cleanup(func() { SetPolicyProvider(nil) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetPolicyProvider(StaticPolicy{"r": {"a"}})
+		}()
+		go func() {
+			defer wg.Done()
+			ctx := WithPrincipal(context.Background(), &Principal{Roles: []string{"r"}})
+			_ = Can(ctx, "a")
+		}()
+	}
+	wg.Wait()
+
+	ctx := WithPrincipal(context.Background(), &Principal{Roles: []string{"r"}})
+	assert.True(t, Can(ctx, "a"))
+	SetPolicyProvider(nil)
+	assert.False(t, Can(WithPrincipal(context.Background(), &Principal{Roles: []string{"r"}}), "a"))
+}
+
+func TestCan_GormPolicy_TenantScoped(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&RolePermission{}))
+	require.NoError(t, db.Create([]RolePermission{
+		{TenantID: "", Role: "member", Permission: "project:read"},
+		{TenantID: "1", Role: "member", Permission: "project:write"},
+		{TenantID: "2", Role: "member", Permission: "billing:read"},
+		{TenantID: testTenantUUID.String(), Role: "member", Permission: "report:read"},
+	}).Error)
+	usePolicy(t, NewGormPolicy(db))
+
+	tenant1 := WithPrincipal(context.Background(), &Principal{TenantID: 1, Roles: []string{"member"}})
+	tenant2 := WithPrincipal(context.Background(), &Principal{TenantID: 2, Roles: []string{"member"}})
+
+	uuidTenant := WithPrincipal(context.Background(), &Principal{TenantID: testTenantUUID, Roles: []string{"member"}})
+
+	// TenantID 為空的權限對所有租戶生效
+	assert.True(t, Can(tenant1, "project:read"))
+	assert.True(t, Can(tenant2, "project:read"))
+
+	assert.True(t, Can(tenant1, "project:write"))
+	assert.False(t, Can(tenant1, "billing:read"))
+	assert.False(t, Can(tenant2, "project:write"))
+	assert.True(t, Can(tenant2, "billing:read"))
+
+	// UUID 租戶
+	assert.True(t, Can(uuidTenant, "project:read"))
+	assert.True(t, Can(uuidTenant, "report:read"))
+	assert.False(t, Can(uuidTenant, "project:write"))
+}
+
+var testTenantUUID = uuid.MustParse("6f1c1c8e-2f59-4a0a-9f3b-3f0a3c1d9e01")
+
+func TestWithPrincipal_UUID(t *testing.T) {
+	userID := uuid.New()
+	ctx := WithPrincipal(context.Background(), &Principal{UserID: userID, TenantID: testTenantUUID})
+
+	retrieved, ok := UserIDAs[uuid.UUID](ctx)
+	assert.True(t, ok)
+	assert.Equal(t, userID, retrieved)
+	tenantID, ok := TenantIDValue(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, testTenantUUID, tenantID)
+	assert.Equal(t, 0, UserIDFrom(ctx))
+
+	// 沒有 UserID 和 TenantID 時不寫入
+	ctx = WithPrincipal(context.Background(), &Principal{Username: "svc"})
+	_, ok = UserIDValue(ctx)
+	assert.False(t, ok)
+	_, ok = TenantIDValue(ctx)
+	assert.False(t, ok)
+}
+
+func TestFormatID(t *testing.T) {
+	assert.Equal(t, "", FormatID(nil))
+	assert.Equal(t, "7", FormatID(7))
+	assert.Equal(t, "7", FormatID(int64(7)))
+	assert.Equal(t, "acme", FormatID("acme"))
+	assert.Equal(t, testTenantUUID.String(), FormatID(testTenantUUID))
+	assert.Equal(t, testTenantUUID.String(), FormatID([16]byte(testTenantUUID)))
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"sync"
+
+	"github.com/irvingos/go-tools/ctxkey"
+)
+
+// Principal 是已认证的调用方。Roles 通过 PolicyProvider 解析为权限，Scopes 直接视为已授予的权限。
+// UserID 和 TenantID 的类型是 ID 中的一种，为 nil 时表示没有
+type Principal struct {
+	UserID   any
+	TenantID any
+	Username string
+	Roles    []string
+	Scopes   []string
+
+	mu          sync.Mutex
+	resolved    bool
+	permissions []string
+}
+
+var principalKey = ctxkey.NewCarried[*Principal]("auth.principal")
+
+// WithPrincipal 保存 principal，同时写入 UserID、TenantID 和 Username
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	if p.UserID != nil {
+		ctx = userIDKey.With(ctx, p.UserID)
+	}
+	if p.TenantID != nil {
+		ctx = tenantIDKey.With(ctx, p.TenantID)
+	}
+	ctx = WithUsername(ctx, p.Username)
+	return principalKey.With(ctx, p)
+}
+
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	return principalKey.From(ctx)
+}
+
+// resolve 每个 principal 只向 PolicyProvider 成功查询一次，查询失败（如 ctx 已取消、数据库暂时不可用）时不缓存，下次重新查询
+func (p *Principal) resolve(ctx context.Context, provider PolicyProvider) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resolved || provider == nil || len(p.Roles) == 0 {
+		return p.permissions, nil
+	}
+	permissions, err := provider.Permissions(ctx, p.TenantID, p.Roles)
+	if err != nil {
+		return nil, err
+	}
+	p.permissions, p.resolved = permissions, true
+	return p.permissions, nil
+}
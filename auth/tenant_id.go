@@ -3,26 +3,29 @@ package auth
 import (
 	"context"
 
-	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/ctxkey"
 )
 
-type tenantIDKey struct{}
+var tenantIDKey = ctxkey.NewCarried[any]("auth.tenant_id")
 
-func WithTenantID(ctx context.Context, tenantID int) context.Context {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		gCtx.Set(tenantIDKey{}, tenantID)
-		return gCtx
-	}
-	return context.WithValue(ctx, tenantIDKey{}, tenantID)
+func WithTenantID[T ID](ctx context.Context, tenantID T) context.Context {
+	return tenantIDKey.With(ctx, tenantID)
 }
 
+// TenantIDFrom 返回 int 类型的租户 ID，其他类型的 ID 使用 TenantIDAs 读取
 func TenantIDFrom(ctx context.Context) int {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		return gCtx.GetInt(tenantIDKey{})
-	}
-	tenantID, ok := ctx.Value(tenantIDKey{}).(int)
-	if !ok {
-		return 0
-	}
+	tenantID, _ := TenantIDAs[int](ctx)
 	return tenantID
 }
+
+// TenantIDValue 返回任意类型的租户 ID，用于不关心具体类型的场景，如 gormx.TenantPlugin
+func TenantIDValue(ctx context.Context) (any, bool) {
+	v, ok := tenantIDKey.From(ctx)
+	return v, ok && v != nil
+}
+
+func TenantIDAs[T ID](ctx context.Context) (T, bool) {
+	v, _ := tenantIDKey.From(ctx)
+	tenantID, ok := v.(T)
+	return tenantID, ok
+}
@@ -3,26 +3,29 @@ package auth
 import (
 	"context"
 
-	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/ctxkey"
 )
 
-type userIDKey struct{}
+var userIDKey = ctxkey.NewCarried[any]("auth.user_id")
 
-func WithUserID(ctx context.Context, userID int) context.Context {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		gCtx.Set(userIDKey{}, userID)
-		return gCtx
-	}
-	return context.WithValue(ctx, userIDKey{}, userID)
+func WithUserID[T ID](ctx context.Context, userID T) context.Context {
+	return userIDKey.With(ctx, userID)
 }
 
+// UserIDFrom 返回 int 类型的用户 ID，其他类型的 ID 使用 UserIDAs 读取
 func UserIDFrom(ctx context.Context) int {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		return gCtx.GetInt(userIDKey{})
-	}
-	userID, ok := ctx.Value(userIDKey{}).(int)
-	if !ok {
-		return 0
-	}
+	userID, _ := UserIDAs[int](ctx)
 	return userID
 }
+
+// UserIDValue 返回任意类型的用户 ID，用于不关心具体类型的场景，如 gormx.TenantPlugin
+func UserIDValue(ctx context.Context) (any, bool) {
+	v, ok := userIDKey.From(ctx)
+	return v, ok && v != nil
+}
+
+func UserIDAs[T ID](ctx context.Context) (T, bool) {
+	v, _ := userIDKey.From(ctx)
+	userID, ok := v.(T)
+	return userID, ok
+}
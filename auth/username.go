@@ -3,26 +3,16 @@ package auth
 import (
 	"context"
 
-	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/ctxkey"
 )
 
-type usernameKey struct{}
+var usernameKey = ctxkey.NewCarried[string]("auth.username")
 
 func WithUsername(ctx context.Context, username string) context.Context {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		gCtx.Set(usernameKey{}, username)
-		return gCtx
-	}
-	return context.WithValue(ctx, usernameKey{}, username)
+	return usernameKey.With(ctx, username)
 }
 
 func UsernameFrom(ctx context.Context) string {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		return gCtx.GetString(usernameKey{})
-	}
-	username, ok := ctx.Value(usernameKey{}).(string)
-	if !ok {
-		return ""
-	}
+	username, _ := usernameKey.From(ctx)
 	return username
 }
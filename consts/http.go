@@ -1,7 +1,8 @@
 package consts
 
 const (
-	HTTP_HEADER_TRACE_ID = "x-request-id"
+	HTTP_HEADER_TRACE_ID    = "x-request-id"
+	HTTP_HEADER_TRACEPARENT = "traceparent"
 
 	HTTP_HEADER_AUTHORIZATION        = "Authorization"
 	HTTP_HEADER_AUTHORIZATION_PREFIX = "Bearer "
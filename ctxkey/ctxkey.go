@@ -0,0 +1,66 @@
+package ctxkey
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Key 是带类型的 context key，每次 New 得到的 key 都互不相同
+//
+//	var userKey = ctxkey.New[*User]("user")
+//	ctx = userKey.With(ctx, u)
+//	u, ok := userKey.From(ctx)
+type Key[T any] struct {
+	name string
+}
+
+func New[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+func (k *Key[T]) With(ctx context.Context, v T) context.Context {
+	return WithValue(ctx, k, v)
+}
+
+func (k *Key[T]) From(ctx context.Context) (T, bool) {
+	return Value[T](ctx, k)
+}
+
+// MustFrom 在 ctx 中没有值时 panic，用于中间件保证已写入的场景
+func (k *Key[T]) MustFrom(ctx context.Context) T {
+	v, ok := k.From(ctx)
+	if !ok {
+		panic(fmt.Sprintf("ctxkey: %s not found in context", k.name))
+	}
+	return v
+}
+
+// WithValue 对 *gin.Context 原地写入 Keys 并返回原 ctx，其他 ctx 使用 context.WithValue 派生。
+// 无法预先声明 Key 的场景（如按名称区分的 key）可以直接使用
+func WithValue(ctx context.Context, key, v any) context.Context {
+	if gCtx, ok := ctx.(*gin.Context); ok {
+		gCtx.Set(key, v)
+		return gCtx
+	}
+	return context.WithValue(ctx, key, v)
+}
+
+// Value 读取 WithValue 写入的值。
+// 从 *gin.Context 派生的 ctx（如 context.WithoutCancel(gCtx)）不会读取 gin.Context 的 Keys：
+// gin.Context 会被下一个请求复用，派生的 ctx 可能在请求结束后读到其他请求的值。
+// 需要在派生的 ctx 中使用的值应同时写入 Request.Context()，并从 Request.Context() 派生
+func Value[T any](ctx context.Context, key any) (T, bool) {
+	if gCtx, ok := ctx.(*gin.Context); ok {
+		v, _ := gCtx.Get(key)
+		t, ok := v.(T)
+		return t, ok
+	}
+	t, ok := ctx.Value(key).(T)
+	return t, ok
+}
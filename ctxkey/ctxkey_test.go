@@ -0,0 +1,62 @@
+package ctxkey
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey_StandardContext(t *testing.T) {
+	key := New[int]("count")
+	ctx := context.Background()
+
+	_, ok := key.From(ctx)
+	assert.False(t, ok)
+	assert.Panics(t, func() { key.MustFrom(ctx) })
+
+	ctx = key.With(ctx, 42)
+	v, ok := key.From(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, 42, v)
+	assert.Equal(t, 42, key.MustFrom(ctx))
+
+	// 同名的 key 互不影響
+	_, ok = New[int]("count").From(ctx)
+	assert.False(t, ok)
+}
+
+func TestKey_GinContext(t *testing.T) {
+	key := New[string]("name")
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+
+	ctx := key.With(c, "alice")
+	assert.Same(t, c, ctx)
+	assert.Equal(t, "alice", key.MustFrom(c))
+
+	// 從 gin.Context 派生的 ctx 不讀取 gin.Context 的 Keys，gin.Context 會被其他請求複用
+	derived, cancel := context.WithTimeout(c, time.Second)
+	defer cancel()
+	_, ok := key.From(derived)
+	assert.False(t, ok)
+	_, ok = key.From(context.WithoutCancel(c))
+	assert.False(t, ok)
+
+	// 派生 ctx 上寫入的值不影響 gin.Context
+	assert.Equal(t, "bob", key.MustFrom(key.With(derived, "bob")))
+	assert.Equal(t, "alice", key.MustFrom(c))
+}
+
+func TestValue_TypeMismatch(t *testing.T) {
+	type k struct{}
+	ctx := WithValue(context.Background(), k{}, "string")
+
+	_, ok := Value[int](ctx, k{})
+	assert.False(t, ok)
+	v, ok := Value[string](ctx, k{})
+	assert.True(t, ok)
+	assert.Equal(t, "string", v)
+}
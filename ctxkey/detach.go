@@ -0,0 +1,58 @@
+package ctxkey
+
+import (
+	"context"
+	"sync"
+)
+
+// Carrier 把 src 中的值复制到 dst，*Key[T] 实现了该接口
+type Carrier interface {
+	Carry(dst, src context.Context) context.Context
+}
+
+func (k *Key[T]) Carry(dst, src context.Context) context.Context {
+	if v, ok := k.From(src); ok {
+		return k.With(dst, v)
+	}
+	return dst
+}
+
+var (
+	mu       sync.RWMutex
+	carriers []Carrier
+)
+
+// Register 声明 Detach 时需要复制的值，通常在包初始化时调用
+func Register(cs ...Carrier) {
+	mu.Lock()
+	defer mu.Unlock()
+	carriers = append(carriers, cs...)
+}
+
+// NewCarried 创建 key 并注册到 Detach
+func NewCarried[T any](name string) *Key[T] {
+	k := New[T](name)
+	Register(k)
+	return k
+}
+
+// Detach 返回一个不会被取消的新 ctx，只包含通过 Register 声明的值。
+// 用于在请求结束后继续执行的后台任务：*gin.Context 会被复用，不能在请求结束后使用，
+// 而 context.Background() 又会丢失 trace id、用户等信息
+//
+//	safego.Go(ctxkey.Detach(c), func(ctx context.Context) { ... })
+func Detach(ctx context.Context) context.Context {
+	return Carry(context.Background(), ctx)
+}
+
+// Carry 把 src 中通过 Register 声明的值复制到 dst，dst 的取消和 deadline 不变。
+// 用于从 *gin.Context 的 Request.Context() 派生新 ctx 时保留只写入了 gin.Context 的值
+func Carry(dst, src context.Context) context.Context {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, c := range carriers {
+		dst = c.Carry(dst, src)
+	}
+	return dst
+}
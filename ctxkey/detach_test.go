@@ -0,0 +1,45 @@
+package ctxkey
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetach(t *testing.T) {
+	carried := NewCarried[string]("carried")
+	notCarried := New[string]("not_carried")
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+	carried.With(c, "a")
+	notCarried.With(c, "b")
+
+	// 在請求處理過程中從 gin.Context 本身 Detach
+	c.Request, _ = http.NewRequest(http.MethodGet, "/", nil)
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+	detached := Detach(c)
+	cancel()
+
+	assert.NoError(t, detached.Err())
+	assert.Nil(t, detached.Done())
+	assert.Equal(t, "a", carried.MustFrom(detached))
+	_, ok := notCarried.From(detached)
+	assert.False(t, ok)
+
+	// 與 gin.Context 不再有關聯
+	carried.With(c, "changed")
+	assert.Equal(t, "a", carried.MustFrom(detached))
+	assert.Nil(t, detached.Value(gin.ContextKey))
+
+	// Carry 保留 dst 的取消
+	parent, cancel := context.WithCancel(context.Background())
+	carriedCtx := Carry(parent, c)
+	cancel()
+	assert.Error(t, carriedCtx.Err())
+	assert.Equal(t, "changed", carried.MustFrom(carriedCtx))
+}
@@ -6,6 +6,7 @@ var (
 	ErrInvalidToken = NewError(1004002, "invalid token")
 	ErrForbidden    = NewError(1004003, "forbidden")
 	ErrNotFound     = NewError(1004004, "resource not found")
+	ErrConflict     = NewError(1004009, "resource conflict")
 
 	ErrInternal = NewError(1005000, "internal server error")
 )
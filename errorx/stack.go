@@ -0,0 +1,89 @@
+package errorx
+
+import (
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const maxStackDepth = 32
+
+type stack []uintptr
+
+// callers 记录调用 Errorf、Wrap 的位置，跳过 runtime.Callers、callers 和 Errorf/Wrap 本身
+func callers() *stack {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(3, pcs[:])
+	s := stack(pcs[:n])
+	return &s
+}
+
+func (s *stack) String() string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(*s)
+	for {
+		f, more := frames.Next()
+		b.WriteString(f.Function)
+		b.WriteString("\n\t")
+		b.WriteString(f.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(f.Line))
+		b.WriteByte('\n')
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// Stack 返回错误链中最早记录的调用栈，即最接近错误源头的位置，没有时返回空字符串
+func Stack(err error) string {
+	var found *stack
+	for err != nil {
+		if e, ok := err.(*wrapped); ok && e.stack != nil {
+			found = e.stack
+		}
+		err = errors.Unwrap(err)
+	}
+	if found == nil {
+		return ""
+	}
+	return found.String()
+}
+
+// Chain 返回从外到内的错误链，每一项是该层自身的描述，不包含内层错误的内容
+func Chain(err error) []string {
+	var chain []string
+	for err != nil {
+		next := errors.Unwrap(err)
+		msg := err.Error()
+		if e, ok := err.(*wrapped); ok {
+			msg = e.own()
+			if e.causeInMessage {
+				next = nil
+			}
+		} else if next != nil {
+			msg = strings.TrimSuffix(msg, ": "+next.Error())
+		}
+		chain = append(chain, msg)
+		err = next
+	}
+	return chain
+}
+
+// Detail 输出错误链和调用栈，用于 %+v 和日志
+func Detail(err error) string {
+	var b strings.Builder
+	for i, msg := range Chain(err) {
+		if i > 0 {
+			b.WriteString("\ncaused by: ")
+		}
+		b.WriteString(msg)
+	}
+	if s := Stack(err); s != "" {
+		b.WriteString("\n")
+		b.WriteString(strings.TrimSuffix(s, "\n"))
+	}
+	return b.String()
+}
@@ -2,7 +2,10 @@ package errorx
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
 )
 
 type Error interface {
@@ -11,6 +14,7 @@ type Error interface {
 	Message() string
 }
 
+// errorx 是 NewError 创建的哨兵错误，只包含 code 和 message，可以安全地用 == 判断
 type errorx struct {
 	code    int
 	message string
@@ -28,6 +32,17 @@ func (e errorx) Message() string {
 	return e.message
 }
 
+// Is 按 code 判断，Errorf 格式化或 Wrap 之后仍然可以用 errors.Is(err, ErrNotFound) 判断
+func (e errorx) Is(target error) bool {
+	t, ok := target.(Error)
+	return ok && t.Code() == e.code
+}
+
+// Format 使 %+v 与 Error 相同，而不是输出结构体字段
+func (e errorx) Format(s fmt.State, verb rune) {
+	format(s, verb, e, e.Error())
+}
+
 func (e errorx) MarshalJSON() ([]byte, error) {
 	type alias struct {
 		Code    int    `json:"code"`
@@ -39,10 +54,116 @@ func (e errorx) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// wrapped 是 Errorf、Wrap 创建的错误。cause 可能是不可比较的类型，用 == 比较值会 panic，
+// 因此以指针返回，== 只在是同一个错误时成立，判断错误类型应使用 errors.Is
+type wrapped struct {
+	errorx
+	// cause 是被包装的原始错误，Message 不包含它，避免把内部错误返回给客户端
+	cause error
+	// text 是 Errorf 参数中包含 error 时完整格式化的文本，message 中的 error 参数已替换为占位文本
+	text string
+	// causeInMessage 为 true 时 text 已经包含 cause（Errorf 的 %w），Error 不再追加
+	causeInMessage bool
+	stack          *stack
+}
+
+// Error 返回 message，有 cause 时以 ": " 拼接 cause，与 fmt.Errorf 的习惯一致
+func (e *wrapped) Error() string {
+	if e.cause == nil || e.causeInMessage {
+		return e.own()
+	}
+	return e.message + ": " + e.cause.Error()
+}
+
+// own 返回该层自身完整的描述
+func (e *wrapped) own() string {
+	if e.text != "" {
+		return e.text
+	}
+	return e.message
+}
+
+func (e *wrapped) Unwrap() error {
+	return e.cause
+}
+
+// Format 支持 %+v 输出完整的错误链和创建时的调用栈，其余动词与 Error 相同
+func (e *wrapped) Format(s fmt.State, verb rune) {
+	format(s, verb, e, e.Error())
+}
+
+func format(s fmt.State, verb rune, err error, msg string) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		_, _ = io.WriteString(s, Detail(err))
+	case verb == 'q':
+		_, _ = fmt.Fprintf(s, "%q", msg)
+	default:
+		_, _ = io.WriteString(s, msg)
+	}
+}
+
+// NewError 创建哨兵错误，一般定义为包级变量，不记录调用栈
 func NewError(code int, message string) Error {
 	return errorx{code: code, message: message}
 }
 
+// Errorf 以 err 的 message 为格式化模板创建新的错误，code 与 err 相同，并记录调用栈。
+// 参数中用 %w 引用的错误作为 cause，可以通过 errors.Is/As/Unwrap 访问。
+// 参数中的 error 只出现在 Error 中，Message 中替换为它的 errorx.Error 的 message，没有时为 ErrInternal 的 message，
+// 避免把内部错误返回给客户端
+//
+//	var ErrLoadUser = errorx.NewError(1005001, "load user %d: %w")
+//	return errorx.Errorf(ErrLoadUser, id, err)
 func Errorf(err Error, args ...any) Error {
-	return errorx{code: err.Code(), message: fmt.Sprintf(err.Message(), args...)}
+	formatted := fmt.Errorf(err.Message(), args...)
+	e := &wrapped{errorx: errorx{code: err.Code(), message: formatted.Error()}, stack: callers()}
+	if cause := errors.Unwrap(formatted); cause != nil {
+		e.cause, e.causeInMessage = cause, true
+	} else if _, ok := formatted.(interface{ Unwrap() []error }); ok {
+		e.cause, e.causeInMessage = formatted, true
+	}
+	if hidden, ok := hideErrors(args); ok {
+		e.text = e.message
+		e.message = fmt.Errorf(err.Message(), hidden...).Error()
+	}
+	return e
+}
+
+// placeholder 在 Message 中代替 Errorf 参数中的 error，实现 error 以便仍然可以用于 %w
+type placeholder string
+
+func (p placeholder) Error() string {
+	return string(p)
+}
+
+// hideErrors 把 args 中的 error 替换为 placeholder，没有 error 时返回 false
+func hideErrors(args []any) ([]any, bool) {
+	var hidden []any
+	for i, arg := range args {
+		err, ok := arg.(error)
+		if !ok {
+			continue
+		}
+		if hidden == nil {
+			hidden = slices.Clone(args)
+		}
+		var target Error
+		if errors.As(err, &target) {
+			hidden[i] = placeholder(target.Message())
+		} else {
+			hidden[i] = placeholder(ErrInternal.Message())
+		}
+	}
+	return hidden, hidden != nil
+}
+
+// Wrap 用 target 的 code 和 message 包装 err，保留 err 作为 cause 并记录调用栈，err 为 nil 时返回 nil
+//
+//	return errorx.Wrap(err, errorx.ErrNotFound)
+func Wrap(err error, target Error) Error {
+	if err == nil {
+		return nil
+	}
+	return &wrapped{errorx: errorx{code: target.Code(), message: target.Message()}, cause: err, stack: callers()}
 }
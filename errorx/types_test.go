@@ -0,0 +1,114 @@
+package errorx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errLoadUser = NewError(1005001, "load user %d: %w")
+
+func TestWrap(t *testing.T) {
+	cause := io.ErrUnexpectedEOF
+	err := Wrap(cause, ErrNotFound)
+
+	// 按 code 判斷，同時保留原始錯誤
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrConflict)
+	assert.Equal(t, cause, errors.Unwrap(err))
+	assert.Equal(t, ErrNotFound.Code(), err.Code())
+
+	// Message 不包含內部錯誤，Error 以 ": " 拼接
+	assert.Equal(t, "resource not found", err.Message())
+	assert.Equal(t, "resource not found: unexpected EOF", err.Error())
+
+	var target Error
+	assert.True(t, errors.As(fmt.Errorf("handler: %w", err), &target))
+	assert.Equal(t, ErrNotFound.Code(), target.Code())
+
+	raw, _ := json.Marshal(err)
+	assert.JSONEq(t, `{"code":1004004,"message":"resource not found"}`, string(raw))
+
+	assert.Nil(t, Wrap(nil, ErrNotFound))
+}
+
+func TestErrorf(t *testing.T) {
+	err := Errorf(NewError(1004004, "user %d not found"), 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, "user 1 not found", err.Error())
+	assert.Nil(t, errors.Unwrap(err))
+
+	// %w 引用的錯誤作為 cause，不會重複出現在 Error 中
+	cause := io.EOF
+	err = Errorf(errLoadUser, 1, cause)
+	assert.ErrorIs(t, err, cause)
+	assert.ErrorIs(t, err, errLoadUser)
+	assert.Equal(t, "load user 1: EOF", err.Error())
+	assert.Equal(t, []string{"load user 1: EOF"}, Chain(err))
+
+	// Message 返回給客戶端，不包含參數中的內部錯誤
+	assert.Equal(t, "load user 1: internal server error", err.Message())
+	err = Errorf(errLoadUser, 2, Wrap(cause, ErrNotFound))
+	assert.Equal(t, "load user 2: resource not found", err.Message())
+	assert.Equal(t, "load user 2: resource not found: EOF", err.Error())
+	err = Errorf(NewError(1005002, "load user %v"), cause)
+	assert.Equal(t, "load user internal server error", err.Message())
+	assert.Equal(t, "load user EOF", err.Error())
+
+	// 哨兵錯誤仍然可以直接比較
+	assert.True(t, ErrNotFound == NewError(1004004, "resource not found"))
+}
+
+func TestStack(t *testing.T) {
+	assert.Empty(t, Stack(ErrNotFound))
+	assert.Empty(t, Stack(io.EOF))
+
+	inner := Wrap(io.EOF, ErrInternal)
+	outer := Wrap(fmt.Errorf("load: %w", inner), ErrNotFound)
+
+	// 取最接近源頭的調用棧
+	stack := Stack(outer)
+	assert.Contains(t, stack, "errorx.TestStack")
+	assert.Contains(t, stack, "types_test.go")
+	assert.Equal(t, Stack(inner), stack)
+
+	assert.Equal(t, []string{"resource not found", "load", "internal server error", "EOF"}, Chain(outer))
+
+	detail := fmt.Sprintf("%+v", outer)
+	assert.True(t, strings.HasPrefix(detail, "resource not found\ncaused by: load\ncaused by: internal server error\ncaused by: EOF\n"))
+	assert.Contains(t, detail, "types_test.go")
+	assert.Equal(t, outer.Error(), fmt.Sprintf("%v", outer))
+	assert.Equal(t, "resource not found: load: internal server error: EOF", fmt.Sprint(outer))
+}
+
+// multiError 是不可比較的錯誤類型
+type multiError []error
+
+func (m multiError) Error() string {
+	return fmt.Sprint([]error(m))
+}
+
+func TestCompare(t *testing.T) {
+	// 哨兵錯誤可以直接用 == 判斷
+	assert.True(t, ErrNotFound == NewError(ErrNotFound.Code(), ErrNotFound.Message()))
+
+	// cause 不可比較時 == 不會 panic，不同的實例互不相等
+	cause := multiError{io.EOF}
+	wrapped := Wrap(cause, ErrNotFound)
+	formatted := Errorf(errLoadUser, 1, cause)
+	assert.NotPanics(t, func() {
+		assert.True(t, wrapped == wrapped)
+		assert.False(t, wrapped == Wrap(cause, ErrNotFound))
+		assert.False(t, formatted == Errorf(errLoadUser, 1, cause))
+		assert.False(t, error(wrapped) == error(ErrNotFound))
+	})
+	assert.ErrorIs(t, wrapped, ErrNotFound)
+	assert.ErrorIs(t, formatted, errLoadUser)
+	assert.Equal(t, ErrNotFound.Message(), fmt.Sprintf("%+v", ErrNotFound))
+}
@@ -0,0 +1,33 @@
+package gormx
+
+import "gorm.io/gorm"
+
+type callbackRegisterer interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+// registerAround 在每种操作的第一个和最后一个 callback 位置注册 before 和 after，
+// 让插件覆盖包括事务在内的所有 callback。before 按操作名（create、query 等）生成
+func registerAround(db *gorm.DB, prefix string, before func(operation string) func(*gorm.DB), after func(*gorm.DB)) error {
+	cb := db.Callback()
+	processors := []struct {
+		operation     string
+		before, after callbackRegisterer
+	}{
+		{"create", cb.Create().Before("*"), cb.Create().After("*")},
+		{"query", cb.Query().Before("*"), cb.Query().After("*")},
+		{"update", cb.Update().Before("*"), cb.Update().After("*")},
+		{"delete", cb.Delete().Before("*"), cb.Delete().After("*")},
+		{"row", cb.Row().Before("*"), cb.Row().After("*")},
+		{"raw", cb.Raw().Before("*"), cb.Raw().After("*")},
+	}
+	for _, p := range processors {
+		if err := p.before.Register(prefix+"_before_"+p.operation, before(p.operation)); err != nil {
+			return err
+		}
+		if err := p.after.Register(prefix+"_after_"+p.operation, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
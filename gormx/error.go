@@ -9,10 +9,27 @@ import (
 )
 
 func IsDuplicateKeyError(err error) bool {
-	var pgErr *pgconn.PgError
-	return errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation
+	return isPgError(err, pgerrcode.UniqueViolation)
+}
+
+func IsSerializationFailureError(err error) bool {
+	return isPgError(err, pgerrcode.SerializationFailure)
+}
+
+func IsDeadlockDetectedError(err error) bool {
+	return isPgError(err, pgerrcode.DeadlockDetected)
+}
+
+// IsRetryableTxError 判断事务是否可以整体重试
+func IsRetryableTxError(err error) bool {
+	return IsSerializationFailureError(err) || IsDeadlockDetectedError(err)
 }
 
 func IsRecordNotFoundError(err error) bool {
 	return errors.Is(err, gorm.ErrRecordNotFound)
 }
+
+func isPgError(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
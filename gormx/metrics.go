@@ -0,0 +1,43 @@
+package gormx
+
+import (
+	"time"
+
+	"github.com/irvingos/go-tools/metrics"
+	"gorm.io/gorm"
+)
+
+const (
+	metricsStartKey     = "gormx:metrics_start"
+	metricsOperationKey = "gormx:metrics_operation"
+)
+
+// MetricsPlugin 按操作和表名统计语句耗时，写入 metrics.DBQueryDuration。
+// 慢查询数由 logx.NewDBLogger 按 SlowSQLThreshold 统计
+//
+//	db.Use(gormx.MetricsPlugin{})
+type MetricsPlugin struct{}
+
+func (MetricsPlugin) Name() string {
+	return "gormx:metrics"
+}
+
+func (MetricsPlugin) Initialize(db *gorm.DB) error {
+	return registerAround(db, "gormx:metrics", metricsBefore, metricsAfter)
+}
+
+func metricsBefore(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		db.InstanceSet(metricsStartKey, time.Now())
+		db.InstanceSet(metricsOperationKey, operation)
+	}
+}
+
+func metricsAfter(db *gorm.DB) {
+	start, ok := db.InstanceGet(metricsStartKey)
+	if !ok {
+		return
+	}
+	operation, _ := db.InstanceGet(metricsOperationKey)
+	metrics.DBQueryDuration.Observe(time.Since(start.(time.Time)).Seconds(), operation.(string), db.Statement.Table)
+}
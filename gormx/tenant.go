@@ -0,0 +1,224 @@
+package gormx
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/google/uuid"
+	"github.com/irvingos/go-tools/auth"
+	"github.com/irvingos/go-tools/ctxkey"
+	"github.com/irvingos/go-tools/errorx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	ErrMissingTenant  = errorx.NewError(1004201, "missing tenant")
+	ErrTenantMismatch = errorx.NewError(1004202, "tenant mismatch")
+	// ErrRawTenantQuery 表示对租户模型执行了 Raw 查询，SQL 已经写好，无法追加 tenant_id 条件
+	ErrRawTenantQuery = errorx.NewError(1005200, "raw query on tenant model")
+)
+
+// Tenanted 是按租户隔离的模型，租户 ID 保存在 tenant_id 列，类型可以是 auth.ID 中的任意一种
+type Tenanted interface {
+	GetTenantID() any
+}
+
+// TenantOf 嵌入到模型中即实现 Tenanted，T 与 auth.WithTenantID 写入的类型一致，如 string、uuid.UUID
+type TenantOf[T auth.ID] struct {
+	TenantID T `gorm:"not null;index"`
+}
+
+func (t *TenantOf[T]) GetTenantID() any {
+	return t.TenantID
+}
+
+func (t *TenantOf[T]) SetTenantID(tenantID T) {
+	t.TenantID = tenantID
+}
+
+// Tenant 是 int 类型租户 ID 的 TenantOf
+type Tenant = TenantOf[int]
+
+var skipTenantKey = ctxkey.NewCarried[bool]("gormx.skip_tenant")
+
+// WithoutTenant 关闭当前 ctx 的租户隔离，用于后台任务等需要跨租户访问的场景
+func WithoutTenant(ctx context.Context) context.Context {
+	return skipTenantKey.With(ctx, true)
+}
+
+func isSkipTenant(ctx context.Context) bool {
+	skip, _ := skipTenantKey.From(ctx)
+	return skip
+}
+
+// TenantPlugin 对实现了 Tenanted 的模型自动做租户隔离：
+// 查询、更新、删除追加 tenant_id 条件，创建时写入 tenant_id。
+// 租户 ID 通过 auth.TenantIDValue 从 Statement.Context 读取，因此需要 WithContext 或经由 tx.BaseRepo.DBFrom；
+// ctx 中没有租户且未调用 WithoutTenant 时返回 ErrMissingTenant。
+// Raw 的 SQL 已经写好，无法追加条件：Raw(...).Find 到租户模型时返回 ErrRawTenantQuery，
+// 需要自行在 SQL 中限定租户并对 ctx 调用 WithoutTenant；
+// Raw(...).Scan/Row/Rows 在回调执行时还不知道结果类型，Exec 不经过查询回调，这些语句都不做隔离，需要自行限定租户
+//
+//	db.Use(gormx.TenantPlugin{})
+type TenantPlugin struct{}
+
+func (TenantPlugin) Name() string {
+	return "gormx:tenant"
+}
+
+func (TenantPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("gormx:tenant_create", tenantCreate); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("gormx:tenant_query", tenantQuery); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("gormx:tenant_row", tenantQuery); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("gormx:tenant_update", tenantUpdate); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("gormx:tenant_delete", tenantUpdate)
+}
+
+// tenantField 返回模型的 tenant_id 字段和转换为字段类型的当前租户，不需要隔离时 field 为 nil
+func tenantField(db *gorm.DB) (*schema.Field, any) {
+	s := db.Statement.Schema
+	if db.Error != nil || s == nil {
+		return nil, nil
+	}
+	if _, ok := reflect.New(s.ModelType).Interface().(Tenanted); !ok {
+		return nil, nil
+	}
+	field := s.LookUpField("TenantID")
+	if field == nil {
+		return nil, nil
+	}
+
+	ctx := db.Statement.Context
+	if isSkipTenant(ctx) {
+		return nil, nil
+	}
+	v, ok := auth.TenantIDValue(ctx)
+	if !ok || reflect.ValueOf(v).IsZero() {
+		_ = db.AddError(ErrMissingTenant)
+		return nil, nil
+	}
+	tenantID, ok := convertTenantID(v, field.FieldType)
+	if !ok {
+		_ = db.AddError(ErrTenantMismatch)
+		return nil, nil
+	}
+	return field, tenantID
+}
+
+// convertTenantID 把 ctx 中的租户 ID 转换为字段类型，如 int 转 int64、字符串形式的 UUID 转 uuid.UUID。
+// 只在同类之间转换，避免 int 被转换为单个字符的 string
+func convertTenantID(v any, t reflect.Type) (any, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Type() == t {
+		return v, true
+	}
+	if s, ok := v.(string); ok && t.ConvertibleTo(uuidType) {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, false
+		}
+		return reflect.ValueOf(id).Convert(t).Interface(), true
+	}
+	if kindClass(rv.Kind()) != kindClass(t.Kind()) || !rv.CanConvert(t) {
+		return nil, false
+	}
+	return rv.Convert(t).Interface(), true
+}
+
+var uuidType = reflect.TypeFor[uuid.UUID]()
+
+func kindClass(k reflect.Kind) reflect.Kind {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflect.Int
+	}
+	return k
+}
+
+func tenantCreate(db *gorm.DB) {
+	field, tenantID := tenantField(db)
+	if field == nil {
+		return
+	}
+
+	ctx := db.Statement.Context
+	set := func(rv reflect.Value) {
+		v, zero := field.ValueOf(ctx, rv)
+		if zero {
+			if err := field.Set(ctx, rv, tenantID); err != nil {
+				_ = db.AddError(err)
+			}
+			return
+		}
+		if !reflect.DeepEqual(v, tenantID) {
+			_ = db.AddError(ErrTenantMismatch)
+		}
+	}
+
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		set(rv)
+	}
+}
+
+func tenantQuery(db *gorm.DB) {
+	field, tenantID := tenantField(db)
+	if field == nil {
+		return
+	}
+	if db.Statement.SQL.Len() > 0 {
+		_ = db.AddError(ErrRawTenantQuery)
+		return
+	}
+	addTenantWhere(db, field, tenantID)
+}
+
+// tenantUpdate 除了追加条件外，还要保留 gorm 对无条件更新/删除的保护：
+// 追加的 tenant_id 条件会让 gorm 认为已经有 WHERE，因此在这里提前检查
+func tenantUpdate(db *gorm.DB) {
+	field, tenantID := tenantField(db)
+	if field == nil {
+		return
+	}
+	if _, ok := db.Statement.Clauses["WHERE"]; !ok && !db.AllowGlobalUpdate && !hasPrimaryKey(db) {
+		_ = db.AddError(gorm.ErrMissingWhereClause)
+		return
+	}
+	addTenantWhere(db, field, tenantID)
+}
+
+func addTenantWhere(db *gorm.DB, field *schema.Field, tenantID any) {
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID},
+	}})
+}
+
+func hasPrimaryKey(db *gorm.DB) bool {
+	rv := db.Statement.ReflectValue
+	if rv.Kind() != reflect.Struct {
+		return rv.Kind() == reflect.Slice && rv.Len() > 0
+	}
+	for _, field := range db.Statement.Schema.PrimaryFields {
+		if _, zero := field.ValueOf(db.Statement.Context, rv); !zero {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package gormx
+
+import (
+	"context"
+	"errors"
+
+	"github.com/irvingos/go-tools/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+)
+
+const tracingSpanKey = "gormx:tracing_span"
+
+// TracingPlugin 为每条语句创建 ctx 中当前 span 的子 span，记录 SQL、表名、影响行数和调用方，
+// 调用方与 logx.NewDBLogger 输出的 caller 相同。ctx 中没有 span 时不做处理，
+// 因此需要 WithContext 或经由 tx.BaseRepo.DBFrom 传入请求的 ctx。
+// 插件不会替换 Statement.Context，后续回调仍能读到 *gin.Context 中的租户、用户等值
+//
+//	db.Use(gormx.TracingPlugin{})
+type TracingPlugin struct{}
+
+func (TracingPlugin) Name() string {
+	return "gormx:tracing"
+}
+
+func (TracingPlugin) Initialize(db *gorm.DB) error {
+	return registerAround(db, "gormx:tracing", tracingBefore, tracingAfter)
+}
+
+func tracingBefore(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil {
+			return
+		}
+		parent, ok := trace.SpanFrom(ctx)
+		if !ok {
+			return
+		}
+
+		// 以 ctx 中的 span 为父 span 单独创建，返回的 ctx 不使用，span 只保存在 Statement 上
+		_, span := trace.Start(context.Background(), "gorm."+operation,
+			trace.WithRemoteParent(parent.TraceID, parent.SpanID),
+			trace.WithKind(trace.SpanKindClient),
+			trace.WithAttributes(map[string]any{
+				"db.system":    db.Dialector.Name(),
+				"db.operation": operation,
+			}))
+		db.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+func tracingAfter(db *gorm.DB) {
+	v, ok := db.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span := v.(*trace.Span)
+	defer span.End()
+
+	// caller 必须在 callback 中直接获取，与 logx 的 db logger 跳过的调用栈层数一致
+	span.SetAttribute("code.caller", utils.FileWithLineNum())
+	span.SetAttribute("db.statement", db.Statement.SQL.String())
+	if table := db.Statement.Table; table != "" {
+		span.SetAttribute("db.sql.table", table)
+	}
+	span.SetAttribute("db.rows_affected", db.Statement.RowsAffected)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+	}
+}
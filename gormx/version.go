@@ -0,0 +1,70 @@
+package gormx
+
+import (
+	"reflect"
+
+	"github.com/irvingos/go-tools/errorx"
+	"gorm.io/gorm"
+)
+
+// Versioned 是支持乐观锁的模型，版本号保存在 version 列
+type Versioned interface {
+	GetVersion() int64
+	SetVersion(version int64)
+}
+
+// Version 嵌入到模型中即实现 Versioned
+type Version struct {
+	Version int64 `gorm:"not null"`
+}
+
+func (v *Version) GetVersion() int64 {
+	return v.Version
+}
+
+func (v *Version) SetVersion(version int64) {
+	v.Version = version
+}
+
+// UpdateVersioned 按主键和当前版本号更新 model 并将版本号加一，
+// 没有行被更新时说明数据已被他人修改，返回 errorx.ErrConflict。
+// fields 为空时只更新非零值字段，否则只更新 fields 中的字段。
+// 主键为零值时返回 gorm.ErrPrimaryKeyRequired，否则 version 条件会匹配所有同版本的行
+func UpdateVersioned(db *gorm.DB, model Versioned, fields ...string) error {
+	if err := requirePrimaryKey(db, model); err != nil {
+		return err
+	}
+	version := model.GetVersion()
+
+	db = db.Model(model).Where("version = ?", version)
+	if len(fields) > 0 {
+		db = db.Select(append(fields[:len(fields):len(fields)], "version"))
+	}
+
+	model.SetVersion(version + 1)
+	res := db.Updates(model)
+	if res.Error != nil {
+		model.SetVersion(version)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		model.SetVersion(version)
+		return errorx.ErrConflict
+	}
+	return nil
+}
+
+func requirePrimaryKey(db *gorm.DB, model any) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	field := stmt.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return gorm.ErrPrimaryKeyRequired
+	}
+	if _, zero := field.ValueOf(db.Statement.Context, reflect.Indirect(reflect.ValueOf(model))); zero {
+		return gorm.ErrPrimaryKeyRequired
+	}
+	return nil
+}
@@ -4,9 +4,14 @@ import (
 	"context"
 	"sync"
 	"sync/atomic"
+
+	"github.com/irvingos/go-tools/metrics"
 )
 
 type RuntimeManager struct {
+	// Name 是 graceful_in_flight 指标的 name label，用于区分多个 RuntimeManager，默认为 default
+	Name string
+
 	wg       sync.WaitGroup
 	shutting atomic.Bool
 }
@@ -23,13 +28,22 @@ func (m *RuntimeManager) Begin() bool {
 		return false
 	}
 
+	metrics.InFlight.Inc(m.metricName())
 	return true
 }
 
 func (m *RuntimeManager) End() {
+	metrics.InFlight.Dec(m.metricName())
 	m.wg.Done()
 }
 
+func (m *RuntimeManager) metricName() string {
+	if m.Name == "" {
+		return "default"
+	}
+	return m.Name
+}
+
 func (m *RuntimeManager) Shutdown(ctx context.Context) error {
 	m.shutting.Store(true)
 
@@ -5,6 +5,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/irvingos/go-tools/metrics"
 )
 
 func TestRuntimeManager_BeginEnd(t *testing.T) {
@@ -198,3 +200,20 @@ func TestRuntimeManager_MultipleShutdown(t *testing.T) {
 		t.Errorf("Second Shutdown() should not return error, got: %v", err)
 	}
 }
+
+func TestRuntimeManager_InFlightMetric(t *testing.T) {
+	m := &RuntimeManager{Name: "test_in_flight"}
+
+	if !m.Begin() || !m.Begin() {
+		t.Fatal("Begin() should return true")
+	}
+	if v := metrics.InFlight.Value("test_in_flight"); v != 2 {
+		t.Errorf("in-flight should be 2, got: %v", v)
+	}
+
+	m.End()
+	m.End()
+	if v := metrics.InFlight.Value("test_in_flight"); v != 0 {
+		t.Errorf("in-flight should be 0 after End(), got: %v", v)
+	}
+}
@@ -0,0 +1,106 @@
+package jwtx
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ClaimIssuer    = "iss"
+	ClaimSubject   = "sub"
+	ClaimAudience  = "aud"
+	ClaimExpiresAt = "exp"
+	ClaimNotBefore = "nbf"
+	ClaimIssuedAt  = "iat"
+)
+
+// Claims 是 JWT 的 payload，解析时数字保留为 json.Number
+type Claims map[string]any
+
+func (c Claims) String(name string) string {
+	switch v := c[name].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
+// Strings 读取字符串数组类型的 claim，字符串按空格拆分，兼容 OAuth2 的 scope 格式
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return strings.Fields(v)
+	case []string:
+		return v
+	case []any:
+		ss := make([]string, 0, len(v))
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				ss = append(ss, s)
+			}
+		}
+		return ss
+	default:
+		return nil
+	}
+}
+
+// Int 读取整数类型的 claim，兼容数字和数字字符串
+func (c Claims) Int(name string) (int, bool) {
+	switch v := c[name].(type) {
+	case json.Number:
+		i, err := v.Int64()
+		return int(i), err == nil
+	case string:
+		i, err := strconv.Atoi(v)
+		return i, err == nil
+	case float64:
+		return int(v), v == float64(int(v))
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
+
+func (c Claims) Time(name string) (time.Time, bool) {
+	switch v := c[name].(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(int64(f), 0), true
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case int64:
+		return time.Unix(v, 0), true
+	case int:
+		return time.Unix(int64(v), 0), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func (c Claims) Subject() string {
+	return c.String(ClaimSubject)
+}
+
+func (c Claims) Issuer() string {
+	return c.String(ClaimIssuer)
+}
+
+// Audience 兼容字符串和字符串数组两种格式
+func (c Claims) Audience() []string {
+	if aud, ok := c[ClaimAudience].(string); ok {
+		return []string{aud}
+	}
+	return c.Strings(ClaimAudience)
+}
@@ -0,0 +1,17 @@
+package jwtx
+
+import (
+	"context"
+
+	"github.com/irvingos/go-tools/ctxkey"
+)
+
+var claimsKey = ctxkey.NewCarried[Claims]("jwtx.claims")
+
+func WithClaims(ctx context.Context, claims Claims) context.Context {
+	return claimsKey.With(ctx, claims)
+}
+
+func ClaimsFrom(ctx context.Context) (Claims, bool) {
+	return claimsKey.From(ctx)
+}
@@ -0,0 +1,253 @@
+package jwtx
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed            = errors.New("jwtx: malformed token")
+	ErrUnsupportedAlgorithm = errors.New("jwtx: unsupported algorithm")
+	ErrInvalidKey           = errors.New("jwtx: invalid key")
+	ErrSignature            = errors.New("jwtx: signature verification failed")
+	ErrExpired              = errors.New("jwtx: token is expired")
+	ErrNotYetValid          = errors.New("jwtx: token is not valid yet")
+	ErrIssuer               = errors.New("jwtx: invalid issuer")
+	ErrAudience             = errors.New("jwtx: invalid audience")
+	ErrMissingExpiration    = errors.New("jwtx: token has no expiration")
+	ErrMissingKeySet        = errors.New("jwtx: key set is not configured")
+)
+
+var encoding = base64.RawURLEncoding
+
+type header struct {
+	Alg Algorithm `json:"alg"`
+	Typ string    `json:"typ,omitempty"`
+	Kid string    `json:"kid,omitempty"`
+}
+
+// Sign 使用 key 对 claims 签名
+func Sign(claims Claims, key Key) (string, error) {
+	h, err := json.Marshal(header{Alg: key.Algorithm, Typ: "JWT", Kid: key.ID})
+	if err != nil {
+		return "", err
+	}
+	p, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encoding.EncodeToString(h) + "." + encoding.EncodeToString(p)
+	sig, err := sign(key, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + encoding.EncodeToString(sig), nil
+}
+
+// Issue 在 claims 上补充 iat、nbf 和 exp 后签名，已经存在的字段不会被覆盖；ttl 不大于 0 时不设置 exp
+func Issue(key Key, claims Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	c := make(Claims, len(claims)+3)
+	for k, v := range claims {
+		c[k] = v
+	}
+	if _, ok := c[ClaimIssuedAt]; !ok {
+		c[ClaimIssuedAt] = now.Unix()
+	}
+	if _, ok := c[ClaimNotBefore]; !ok {
+		c[ClaimNotBefore] = now.Unix()
+	}
+	if _, ok := c[ClaimExpiresAt]; !ok && ttl > 0 {
+		c[ClaimExpiresAt] = now.Add(ttl).Unix()
+	}
+	return Sign(c, key)
+}
+
+type VerifyOptions struct {
+	KeySet KeySet
+	// Issuer 不为空时校验 iss
+	Issuer string
+	// Audience 不为空时要求 aud 中包含该值
+	Audience string
+	// ClockSkew 是校验 exp 和 nbf 时允许的时钟误差
+	ClockSkew time.Duration
+	// RequireExp 为 true 时拒绝没有 exp 的 token，避免签发的 token 永久有效
+	RequireExp bool
+	Now        func() time.Time
+}
+
+// Verify 校验签名和 exp、nbf、iss、aud，成功时返回 claims。o 或 KeySet 为空时返回 ErrMissingKeySet
+func Verify(token string, o *VerifyOptions) (Claims, error) {
+	if o == nil || o.KeySet == nil {
+		return nil, ErrMissingKeySet
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, err
+	}
+	sig, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	keys, err := o.KeySet.Keys(h.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signingInput := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		// 只使用与声明的算法一致的密钥，防止算法混淆攻击
+		if key.Algorithm != h.Alg {
+			continue
+		}
+		if verify(key, signingInput, sig) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrSignature
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := validate(claims, o); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func validate(claims Claims, o *VerifyOptions) error {
+	now := time.Now()
+	if o.Now != nil {
+		now = o.Now()
+	}
+
+	exp, ok := claims.Time(ClaimExpiresAt)
+	if !ok && o.RequireExp {
+		return ErrMissingExpiration
+	}
+	if ok && !now.Before(exp.Add(o.ClockSkew)) {
+		return ErrExpired
+	}
+	if nbf, ok := claims.Time(ClaimNotBefore); ok && now.Add(o.ClockSkew).Before(nbf) {
+		return ErrNotYetValid
+	}
+	if o.Issuer != "" && claims.Issuer() != o.Issuer {
+		return ErrIssuer
+	}
+	if o.Audience != "" && !slices.Contains(claims.Audience(), o.Audience) {
+		return ErrAudience
+	}
+	return nil
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := encoding.DecodeString(seg)
+	if err != nil {
+		return ErrMalformed
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(v); err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
+
+func sign(key Key, input []byte) ([]byte, error) {
+	digest := sha256.Sum256(input)
+	switch key.Algorithm {
+	case HS256:
+		secret, ok := key.Key.([]byte)
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	case RS256:
+		priv, ok := key.Key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+		return rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, digest[:])
+	case ES256:
+		// ES256 只能使用 P-256 的密钥，其他曲线的 r、s 超过 32 字节
+		priv, ok := key.Key.(*ecdsa.PrivateKey)
+		if !ok || priv.Curve != elliptic.P256() {
+			return nil, ErrInvalidKey
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS 要求 ES256 签名为定长的 r || s
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, key.Algorithm)
+	}
+}
+
+func verify(key Key, input, sig []byte) error {
+	digest := sha256.Sum256(input)
+	switch key.Algorithm {
+	case HS256:
+		expected, err := sign(key, input)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(expected, sig) {
+			return ErrSignature
+		}
+		return nil
+	case RS256:
+		pub, ok := key.verificationKey().(*rsa.PublicKey)
+		if !ok {
+			return ErrInvalidKey
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig)
+	case ES256:
+		pub, ok := key.verificationKey().(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() {
+			return ErrInvalidKey
+		}
+		if len(sig) != 64 {
+			return ErrSignature
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return ErrSignature
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, key.Algorithm)
+	}
+}
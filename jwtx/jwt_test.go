@@ -0,0 +1,161 @@
+package jwtx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKeys(t *testing.T) []Key {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return []Key{
+		{ID: "hs", Algorithm: HS256, Key: []byte("secret")},
+		{ID: "rs", Algorithm: RS256, Key: rsaKey},
+		{ID: "es", Algorithm: ES256, Key: ecKey},
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	for _, key := range testKeys(t) {
+		t.Run(string(key.Algorithm), func(t *testing.T) {
+			token, err := Issue(key, Claims{ClaimSubject: "42", "name": "alice"}, time.Minute)
+			require.NoError(t, err)
+
+			// 驗簽只需要公鑰
+			verifyKey := Key{ID: key.ID, Algorithm: key.Algorithm, Key: key.verificationKey()}
+			claims, err := Verify(token, &VerifyOptions{KeySet: StaticKeySet{verifyKey}})
+			require.NoError(t, err)
+			assert.Equal(t, "42", claims.Subject())
+			assert.Equal(t, "alice", claims.String("name"))
+
+			id, ok := claims.Int(ClaimSubject)
+			assert.True(t, ok)
+			assert.Equal(t, 42, id)
+
+			// 篡改 payload
+			parts := strings.Split(token, ".")
+			forged, _ := Sign(Claims{ClaimSubject: "1"}, Key{Algorithm: HS256, Key: []byte("other")})
+			parts[1] = strings.Split(forged, ".")[1]
+			_, err = Verify(strings.Join(parts, "."), &VerifyOptions{KeySet: StaticKeySet{verifyKey}})
+			assert.ErrorIs(t, err, ErrSignature)
+		})
+	}
+}
+
+func TestVerify_KeyRotation(t *testing.T) {
+	keys := testKeys(t)
+	oldKey := Key{ID: "old", Algorithm: HS256, Key: []byte("old-secret")}
+	newKey := Key{ID: "new", Algorithm: HS256, Key: []byte("new-secret")}
+	keySet := StaticKeySet{newKey, oldKey}
+
+	// 按 kid 選擇密鑰，輪換期間新舊 token 都可以通過
+	for _, key := range []Key{oldKey, newKey} {
+		token, err := Issue(key, Claims{ClaimSubject: "1"}, time.Minute)
+		require.NoError(t, err)
+		_, err = Verify(token, &VerifyOptions{KeySet: keySet})
+		assert.NoError(t, err)
+	}
+
+	// 沒有 kid 時嘗試所有密鑰
+	token, err := Issue(Key{Algorithm: HS256, Key: []byte("old-secret")}, Claims{ClaimSubject: "1"}, time.Minute)
+	require.NoError(t, err)
+	_, err = Verify(token, &VerifyOptions{KeySet: keySet})
+	assert.NoError(t, err)
+
+	// 已移除的密鑰
+	_, err = Verify(token, &VerifyOptions{KeySet: StaticKeySet{newKey}})
+	assert.ErrorIs(t, err, ErrSignature)
+
+	// 聲明的算法與密鑰不一致
+	token, err = Issue(Key{ID: "rs", Algorithm: HS256, Key: []byte("secret")}, Claims{ClaimSubject: "1"}, time.Minute)
+	require.NoError(t, err)
+	_, err = Verify(token, &VerifyOptions{KeySet: StaticKeySet(keys)})
+	assert.ErrorIs(t, err, ErrSignature)
+}
+
+func TestVerify_Claims(t *testing.T) {
+	key := Key{Algorithm: HS256, Key: []byte("secret")}
+	keySet := StaticKeySet{key}
+	now := time.Now()
+
+	cases := []struct {
+		name   string
+		claims Claims
+		opts   VerifyOptions
+		err    error
+	}{
+		{name: "expired", claims: Claims{ClaimExpiresAt: now.Add(-time.Minute).Unix()}, err: ErrExpired},
+		{name: "expired within skew", claims: Claims{ClaimExpiresAt: now.Add(-time.Minute).Unix()}, opts: VerifyOptions{ClockSkew: 2 * time.Minute}},
+		{name: "not before", claims: Claims{ClaimNotBefore: now.Add(time.Minute).Unix()}, err: ErrNotYetValid},
+		{name: "not before within skew", claims: Claims{ClaimNotBefore: now.Add(time.Minute).Unix()}, opts: VerifyOptions{ClockSkew: 2 * time.Minute}},
+		{name: "issuer", claims: Claims{ClaimIssuer: "a"}, opts: VerifyOptions{Issuer: "b"}, err: ErrIssuer},
+		{name: "issuer ok", claims: Claims{ClaimIssuer: "a"}, opts: VerifyOptions{Issuer: "a"}},
+		{name: "audience", claims: Claims{ClaimAudience: []string{"x", "y"}}, opts: VerifyOptions{Audience: "z"}, err: ErrAudience},
+		{name: "audience list", claims: Claims{ClaimAudience: []string{"x", "y"}}, opts: VerifyOptions{Audience: "y"}},
+		{name: "audience string", claims: Claims{ClaimAudience: "y"}, opts: VerifyOptions{Audience: "y"}},
+		{name: "require exp", claims: Claims{ClaimSubject: "1"}, opts: VerifyOptions{RequireExp: true}, err: ErrMissingExpiration},
+		{name: "require exp ok", claims: Claims{ClaimExpiresAt: now.Add(time.Minute).Unix()}, opts: VerifyOptions{RequireExp: true}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := Sign(tc.claims, key)
+			require.NoError(t, err)
+
+			tc.opts.KeySet = keySet
+			tc.opts.Now = func() time.Time { return now }
+			_, err = Verify(token, &tc.opts)
+			if tc.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestES256_InvalidCurve(t *testing.T) {
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	// 非 P-256 的密鑰不能用於 ES256，返回錯誤而不是 panic
+	_, err = Issue(Key{Algorithm: ES256, Key: p384}, Claims{ClaimSubject: "1"}, time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	token, err := Issue(Key{Algorithm: ES256, Key: p256}, Claims{ClaimSubject: "1"}, time.Minute)
+	require.NoError(t, err)
+	wrongKey := Key{Algorithm: ES256, Key: &p384.PublicKey}
+	assert.ErrorIs(t, verify(wrongKey, []byte("input"), make([]byte, 64)), ErrInvalidKey)
+	_, err = Verify(token, &VerifyOptions{KeySet: StaticKeySet{wrongKey}})
+	assert.ErrorIs(t, err, ErrSignature)
+}
+
+func TestVerify_MissingKeySet(t *testing.T) {
+	token, err := Issue(Key{Algorithm: HS256, Key: []byte("secret")}, Claims{ClaimSubject: "1"}, time.Minute)
+	require.NoError(t, err)
+
+	_, err = Verify(token, nil)
+	assert.ErrorIs(t, err, ErrMissingKeySet)
+	_, err = Verify(token, &VerifyOptions{})
+	assert.ErrorIs(t, err, ErrMissingKeySet)
+}
+
+func TestVerify_Malformed(t *testing.T) {
+	keySet := StaticKeySet{{Algorithm: HS256, Key: []byte("secret")}}
+	for _, token := range []string{"", "a.b", "a.b.c", "!!.e30.sig"} {
+		_, err := Verify(token, &VerifyOptions{KeySet: keySet})
+		assert.ErrorIs(t, err, ErrMalformed, token)
+	}
+}
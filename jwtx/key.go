@@ -0,0 +1,57 @@
+package jwtx
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+)
+
+type Algorithm string
+
+const (
+	HS256 Algorithm = "HS256"
+	RS256 Algorithm = "RS256"
+	ES256 Algorithm = "ES256"
+)
+
+// Key 是签名或验签使用的密钥。
+// HS256 使用 []byte；RS256 使用 *rsa.PrivateKey 签名、*rsa.PublicKey 验签；ES256 使用 *ecdsa.PrivateKey / *ecdsa.PublicKey。
+// 私钥同样可以用于验签
+type Key struct {
+	ID        string
+	Algorithm Algorithm
+	Key       any
+}
+
+func (k Key) verificationKey() any {
+	switch key := k.Key.(type) {
+	case *rsa.PrivateKey:
+		return &key.PublicKey
+	case *ecdsa.PrivateKey:
+		return &key.PublicKey
+	default:
+		return key
+	}
+}
+
+// KeySet 提供验签密钥，实现方可以从配置或 JWKS 动态加载以支持密钥轮换
+type KeySet interface {
+	// Keys 返回 kid 对应的密钥；token 没有 kid 时 kid 为空，应返回所有候选密钥
+	Keys(kid string) ([]Key, error)
+}
+
+// StaticKeySet 是固定的密钥集合，轮换期间可以同时放入新旧密钥
+type StaticKeySet []Key
+
+// Keys implements KeySet.
+func (s StaticKeySet) Keys(kid string) ([]Key, error) {
+	if kid == "" {
+		return s, nil
+	}
+	var keys []Key
+	for _, k := range s {
+		if k.ID == kid {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
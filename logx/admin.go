@@ -0,0 +1,89 @@
+package logx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// errUnknownLogger 表示 Logger 还没有通过 Named 创建，管理接口不创建新的 Logger，避免任意名称占用内存
+var errUnknownLogger = errors.New("logx: unknown logger")
+
+type levelsResponse struct {
+	Level   string            `json:"level"`
+	Loggers map[string]string `json:"loggers"`
+}
+
+type setLevelRequest struct {
+	// Logger 为空时修改全局级别
+	Logger string `json:"logger"`
+	// Level 为空时取消 Logger 单独设置的级别
+	Level string `json:"level"`
+}
+
+// LevelHandler 是查询和修改日志级别的管理接口，应只暴露在内网或加上鉴权：
+//
+//	GET                                     返回全局和各个 Logger 生效的级别
+//	PUT {"level":"debug"}                   修改全局级别
+//	PUT {"logger":"gorm","level":"debug"}   修改 Logger 的级别
+//	PUT {"logger":"gorm"}                   恢复 Logger 跟随全局级别
+//
+// 只能修改已经创建的 Logger，未知的名称返回 404
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var req setLevelRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := setLevel(req); err != nil {
+				status := http.StatusBadRequest
+				if errors.Is(err, errUnknownLogger) {
+					status = http.StatusNotFound
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		res := levelsResponse{Level: GetLevel().String(), Loggers: map[string]string{}}
+		for name, level := range Levels() {
+			res.Loggers[name] = level.String()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+	})
+}
+
+func setLevel(req setLevelRequest) error {
+	var logger *Logger
+	if req.Logger != "" {
+		l, ok := lookup(req.Logger)
+		if !ok {
+			return fmt.Errorf("%w: %s", errUnknownLogger, req.Logger)
+		}
+		logger = l
+	}
+	if logger != nil && req.Level == "" {
+		logger.ResetLevel()
+		return nil
+	}
+	level, err := ParseLevel(req.Level)
+	if err != nil {
+		return err
+	}
+	if logger == nil {
+		SetLevel(level)
+	} else {
+		logger.SetLevel(level)
+	}
+	return nil
+}
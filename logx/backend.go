@@ -0,0 +1,31 @@
+package logx
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Backend 负责把日志写到具体的实现，fields 已经包含 trace_id、caller 等字段
+type Backend interface {
+	Enabled(ctx context.Context, level Level) bool
+	Log(ctx context.Context, level Level, msg string, fields map[Field]any)
+}
+
+func NewLogrusBackend(logger *logrus.Logger) Backend {
+	return &logrusBackend{logger: logger}
+}
+
+type logrusBackend struct {
+	logger *logrus.Logger
+}
+
+// Enabled implements Backend.
+func (b *logrusBackend) Enabled(ctx context.Context, level Level) bool {
+	return b.logger.IsLevelEnabled(level)
+}
+
+// Log implements Backend.
+func (b *logrusBackend) Log(ctx context.Context, level Level, msg string, fields map[Field]any) {
+	b.logger.WithContext(ctx).WithFields(fields).Log(level, msg)
+}
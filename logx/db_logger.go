@@ -7,30 +7,27 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/ctxkey"
+	"github.com/irvingos/go-tools/metrics"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/utils"
 )
 
-type traceSQLKey struct{}
+var traceSQLKey = ctxkey.NewCarried[bool]("logx.trace_sql")
 
 func WithTraceSQL(ctx context.Context) context.Context {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		gCtx.Set(traceSQLKey{}, true)
-		return gCtx
-	}
-	return context.WithValue(ctx, traceSQLKey{}, true)
+	return traceSQLKey.With(ctx, true)
 }
 
 func isTraceSQL(ctx context.Context) bool {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		return gCtx.GetBool(traceSQLKey{})
-	}
-	trace, ok := ctx.Value(traceSQLKey{}).(bool)
-	return ok && trace
+	trace, _ := traceSQLKey.From(ctx)
+	return trace
 }
 
+// dbLogger 的级别可以通过 Named("gorm").SetLevel 单独调整
+var dbLogger = Named("gorm")
+
 type DBLoggerOptions struct {
 	SlowSQLThreshold time.Duration
 }
@@ -55,30 +52,34 @@ func (l *tracedDBLogger) LogMode(level logger.LogLevel) logger.Interface {
 
 // 默认的方法直接调用 Entry(ctx, utils.FileWithLineNum()).XXX，由 logx 完成 TraceID 输出
 func (l *tracedDBLogger) Error(ctx context.Context, msg string, data ...any) {
-	WithContext(ctx).WithCaller(utils.FileWithLineNum()).Errorf(msg, data...)
+	dbLogger.WithContext(ctx).WithCaller(utils.FileWithLineNum()).Errorf(msg, data...)
 }
 
 func (l *tracedDBLogger) Info(ctx context.Context, msg string, data ...any) {
-	WithContext(ctx).WithCaller(utils.FileWithLineNum()).Infof(msg, data...)
+	dbLogger.WithContext(ctx).WithCaller(utils.FileWithLineNum()).Infof(msg, data...)
 }
 
 func (l *tracedDBLogger) Warn(ctx context.Context, msg string, data ...any) {
-	WithContext(ctx).WithCaller(utils.FileWithLineNum()).Warnf(msg, data...)
+	dbLogger.WithContext(ctx).WithCaller(utils.FileWithLineNum()).Warnf(msg, data...)
 }
 
 // Trace 方法对输出进行定制，输出 gorm 提供的 SQL 调用方
 func (l *tracedDBLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	isSlow := l.SlowSQLThreshold != 0 && elapsed > l.SlowSQLThreshold
+	// 慢查询计数不受日志级别影响，Silent 时同样统计
+	if isSlow {
+		metrics.DBSlowQueriesTotal.Inc()
+	}
 	if l.level == logger.Silent {
 		return
 	}
 
-	elapsed := time.Since(begin)
 	sql, rows := fc()
 	// caller 必须在这里获取，不能是在 emit 方法里获取，否则 caller 将会是 db_logger.go
 	caller := utils.FileWithLineNum()
 
 	isErr := err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, context.Canceled)
-	isSlow := l.SlowSQLThreshold != 0 && elapsed > l.SlowSQLThreshold
 
 	if isTraceSQL(ctx) {
 		l.emit(ctx, sql, caller, rows, elapsed, isSlow, err)
@@ -105,7 +106,7 @@ func (l *tracedDBLogger) Trace(ctx context.Context, begin time.Time, fc func() (
 }
 
 func (l *tracedDBLogger) emit(ctx context.Context, sql, caller string, rows int64, elapsed time.Duration, isSlow bool, err error) {
-	entry := WithContext(ctx).
+	entry := dbLogger.WithContext(ctx).
 		WithCaller(caller).
 		WithField("sql", strings.ReplaceAll(sql, "\"", "'")).
 		WithField("rows", rowsOrDash(rows)).
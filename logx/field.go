@@ -5,9 +5,17 @@ type Field = string
 
 const (
 	// base
-	FieldTraceID  Field = "trace_id"
-	FieldUsername Field = "username"
-	FieldCaller   Field = "caller"
+	FieldTraceID   Field = "trace_id"
+	FieldSpanID    Field = "span_id"
+	FieldRequestID Field = "request_id"
+	FieldUsername  Field = "username"
+	FieldCaller    Field = "caller"
+	FieldError     Field = "error"
+	FieldLogger    Field = "logger"
+
+	// sampling
+	FieldSampleKey  Field = "sample_key"
+	FieldSuppressed Field = "suppressed"
 
 	// http
 	FieldLatency  Field = "latency"
@@ -26,4 +34,14 @@ const (
 	FieldFunction Field = "function"
 	FieldRecover  Field = "recover"
 	FieldStack    Field = "stack"
+
+	// auth
+	FieldClaim Field = "claim"
+
+	// tx
+	FieldAttempt Field = "attempt"
+
+	// outbox
+	FieldOutboxID Field = "outbox_id"
+	FieldTopic    Field = "topic"
 )
@@ -0,0 +1,122 @@
+package logx
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/irvingos/go-tools/ctxkey"
+	"github.com/sirupsen/logrus"
+)
+
+var rootLevel atomic.Uint32
+
+func init() {
+	rootLevel.Store(uint32(LevelInfo))
+}
+
+// SetLevel 在运行时修改全局级别，没有单独设置级别的 Logger 都会跟随
+func SetLevel(level Level) {
+	rootLevel.Store(uint32(level))
+}
+
+func GetLevel() Level {
+	return Level(rootLevel.Load())
+}
+
+func ParseLevel(s string) (Level, error) {
+	return logrus.ParseLevel(s)
+}
+
+// Enabled 判断 ctx 下全局是否会输出 level 级别的日志
+func Enabled(ctx context.Context, level Level) bool {
+	return enabled(ctx, nil, level)
+}
+
+// Logger 是具名的子 logger，可以单独设置级别，输出时带上 logger 字段
+type Logger struct {
+	name string
+	// level 为 0 时跟随全局级别，否则为 Level + 1
+	level atomic.Uint32
+}
+
+var (
+	loggersMu sync.Mutex
+	loggers   = map[string]*Logger{}
+)
+
+// lookup 返回已经创建的 Logger，不存在时不创建
+func lookup(name string) (*Logger, bool) {
+	loggersMu.Lock()
+	defer loggersMu.Unlock()
+	l, ok := loggers[name]
+	return l, ok
+}
+
+// Named 返回名为 name 的 Logger，相同名称返回同一个实例
+func Named(name string) *Logger {
+	loggersMu.Lock()
+	defer loggersMu.Unlock()
+	l, ok := loggers[name]
+	if !ok {
+		l = &Logger{name: name}
+		loggers[name] = l
+	}
+	return l
+}
+
+func (l *Logger) Name() string {
+	return l.name
+}
+
+func (l *Logger) SetLevel(level Level) {
+	l.level.Store(uint32(level) + 1)
+}
+
+// ResetLevel 取消单独设置的级别，恢复跟随全局级别
+func (l *Logger) ResetLevel() {
+	l.level.Store(0)
+}
+
+// Level 返回生效的级别，nil 表示全局
+func (l *Logger) Level() Level {
+	if l != nil {
+		if level := l.level.Load(); level != 0 {
+			return Level(level - 1)
+		}
+	}
+	return GetLevel()
+}
+
+// Enabled 判断 ctx 下是否会输出 level 级别的日志，用于跳过代价较高的日志内容
+func (l *Logger) Enabled(ctx context.Context, level Level) bool {
+	return enabled(ctx, l, level)
+}
+
+func (l *Logger) WithContext(ctx context.Context) *E {
+	return newEntry(ctx, l).WithCaller(defaultCaller())
+}
+
+// Levels 返回所有具名 Logger 生效的级别
+func Levels() map[string]Level {
+	loggersMu.Lock()
+	defer loggersMu.Unlock()
+	levels := make(map[string]Level, len(loggers))
+	for name, l := range loggers {
+		levels[name] = l.Level()
+	}
+	return levels
+}
+
+var levelKey = ctxkey.NewCarried[Level]("logx.level")
+
+// WithLevel 为当前请求临时提高日志级别（如输出 debug），不会降低全局或 Logger 的级别。
+// 对 Init 创建的所有 Sink 生效，包括设置了固定级别的 Sink；
+// 使用 Options.Backend 时 Backend.Enabled 仍然会过滤，例如 slog.Handler 自身的级别高于 debug 时不会输出
+func WithLevel(ctx context.Context, level Level) context.Context {
+	return levelKey.With(ctx, level)
+}
+
+func levelFrom(ctx context.Context) (Level, bool) {
+	return levelKey.From(ctx)
+}
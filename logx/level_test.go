@@ -0,0 +1,134 @@
+package logx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLevelTest(t *testing.T) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	Init(&Options{Format: FormatJson, Output: buf})
+	t.Cleanup(func() {
+		for name := range Levels() {
+			Named(name).ResetLevel()
+		}
+		Init(&Options{})
+	})
+	return buf
+}
+
+func messages(t *testing.T, buf *bytes.Buffer) []string {
+	var msgs []string
+	for _, entry := range decodeLines(t, buf) {
+		msgs = append(msgs, entry["msg"].(string))
+	}
+	buf.Reset()
+	return msgs
+}
+
+func TestSetLevel(t *testing.T) {
+	buf := setupLevelTest(t)
+	ctx := context.Background()
+
+	WithContext(ctx).Debug("hidden")
+	SetLevel(LevelDebug)
+	assert.Equal(t, LevelDebug, GetLevel())
+	WithContext(ctx).Debug("shown")
+
+	assert.Equal(t, []string{"shown"}, messages(t, buf))
+}
+
+func TestNamed(t *testing.T) {
+	buf := setupLevelTest(t)
+	ctx := context.Background()
+	sql := Named("test_sql")
+	assert.Same(t, sql, Named("test_sql"))
+
+	// 未單獨設置時跟隨全局級別
+	sql.WithContext(ctx).Debug("hidden")
+	sql.SetLevel(LevelDebug)
+	sql.WithContext(ctx).Debug("sql debug")
+	WithContext(ctx).Debug("root hidden")
+
+	entries := decodeLines(t, buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "sql debug", entries[0]["msg"])
+	assert.Equal(t, "test_sql", entries[0][FieldLogger])
+	assert.Contains(t, entries[0][FieldCaller], "level_test.go")
+	buf.Reset()
+
+	sql.SetLevel(LevelError)
+	SetLevel(LevelDebug)
+	sql.WithContext(ctx).Warn("hidden")
+	sql.ResetLevel()
+	sql.WithContext(ctx).Warn("sql warn")
+	assert.Equal(t, []string{"sql warn"}, messages(t, buf))
+}
+
+func TestWithLevel(t *testing.T) {
+	buf := setupLevelTest(t)
+	ctx := WithLevel(context.Background(), LevelDebug)
+
+	WithContext(ctx).Debug("request debug")
+	WithContext(context.Background()).Debug("hidden")
+	// 不會降低級別
+	WithContext(WithLevel(context.Background(), LevelError)).Info("info")
+
+	assert.Equal(t, []string{"request debug", "info"}, messages(t, buf))
+}
+
+func TestLevelHandler(t *testing.T) {
+	setupLevelTest(t)
+	Named("test_http")
+	h := LevelHandler()
+
+	serve := func(method, body string) (int, levelsResponse) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/log/level", strings.NewReader(body)))
+		var res levelsResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		}
+		return w.Code, res
+	}
+
+	code, res := serve(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "info", res.Level)
+	assert.Equal(t, "info", res.Loggers["test_http"])
+
+	code, res = serve(http.MethodPut, `{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "debug", res.Level)
+	assert.Equal(t, "debug", res.Loggers["test_http"])
+
+	code, res = serve(http.MethodPut, `{"logger":"test_http","level":"warn"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "debug", res.Level)
+	assert.Equal(t, "warning", res.Loggers["test_http"])
+
+	_, res = serve(http.MethodPut, `{"logger":"test_http"}`)
+	assert.Equal(t, "debug", res.Loggers["test_http"])
+
+	code, _ = serve(http.MethodPut, `{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 未知的 Logger 返回 404，不會被創建
+	code, _ = serve(http.MethodPut, `{"logger":"test_http_unknown","level":"debug"}`)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = serve(http.MethodPut, `{"logger":"test_http_unknown"}`)
+	assert.Equal(t, http.StatusNotFound, code)
+	_, res = serve(http.MethodGet, "")
+	assert.NotContains(t, res.Loggers, "test_http_unknown")
+
+	code, _ = serve(http.MethodDelete, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
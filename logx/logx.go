@@ -2,15 +2,20 @@ package logx
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
 
+	"github.com/irvingos/go-tools/errorx"
 	"github.com/irvingos/go-tools/timex"
 	"github.com/irvingos/go-tools/trace"
 	"github.com/sirupsen/logrus"
 )
 
-var rootEntry *logrus.Entry
+var (
+	backend Backend
+	exit    = os.Exit
+)
 
 type Format string
 
@@ -21,12 +26,31 @@ const (
 
 type Level = logrus.Level
 
+const (
+	LevelPanic = logrus.PanicLevel
+	LevelFatal = logrus.FatalLevel
+	LevelError = logrus.ErrorLevel
+	LevelWarn  = logrus.WarnLevel
+	LevelInfo  = logrus.InfoLevel
+	LevelDebug = logrus.DebugLevel
+	LevelTrace = logrus.TraceLevel
+)
+
 type Options struct {
 	Format          Format
 	TimestampFormat timex.Format
-	Level           logrus.Level
+	Level           Level
 	Output          io.Writer
-	Hooks           []logrus.Hook
+	// Sinks 不为空时同时输出到多个目标，忽略 Output
+	Sinks []Sink
+	// Hooks 只挂在第一个输出目标上，避免重复触发
+	Hooks []logrus.Hook
+	// Backend 不为空时日志写到 Backend，其余输出相关的配置只对默认的 logrus 生效
+	Backend Backend
+	// Redact 不为空时对所有日志脱敏，一般使用 DefaultRedactOptions
+	Redact *RedactOptions
+	// Sampling 配置采样，为空时只有调用了 E.Sampled 的日志按默认配置采样
+	Sampling *SamplingOptions
 }
 
 func (o *Options) normalize() {
@@ -38,73 +62,245 @@ func (o *Options) normalize() {
 		o.TimestampFormat = timex.Second
 	}
 	if o.Level == 0 {
-		o.Level = logrus.InfoLevel
+		o.Level = LevelInfo
 	}
 	if o.Output == nil {
 		o.Output = os.Stdout
 	}
+	if len(o.Sinks) == 0 {
+		o.Sinks = []Sink{{Output: o.Output}}
+	}
+	for i := range o.Sinks {
+		if o.Sinks[i].Format == "" {
+			o.Sinks[i].Format = o.Format
+		}
+	}
 }
 
+// Init 初始化全局日志，重复调用时会关闭上一次打开的文件。打开滚动文件失败时 panic
 func Init(o *Options) {
 	o.normalize()
+	_ = Close()
+	setRedactor(o.Redact)
+	setSampler(o.Sampling)
+
+	if o.Backend != nil {
+		SetLevel(o.Level)
+		backend = o.Backend
+		return
+	}
 
-	base := logrus.New()
-	switch o.Format {
-	case FormatText:
-		base.SetFormatter(&logrus.TextFormatter{
-			TimestampFormat: o.TimestampFormat.String(),
-			FullTimestamp:   true,
-		})
-	case FormatJson:
-		base.SetFormatter(&logrus.JSONFormatter{
-			TimestampFormat: o.TimestampFormat.String(),
-		})
+	backends := make(sinks, 0, len(o.Sinks))
+	for i, sink := range o.Sinks {
+		output := sink.Output
+		if sink.Rotate != nil {
+			f, err := NewRotatingFile(sink.Rotate)
+			if err != nil {
+				panic(err)
+			}
+			closersMu.Lock()
+			closers = append(closers, f)
+			closersMu.Unlock()
+			output = f
+		}
+
+		base := logrus.New()
+		base.SetFormatter(newFormatter(sink.Format, o.TimestampFormat))
+		// 级别由 sinks 判断，logrus 本身不再过滤
+		base.SetLevel(LevelTrace)
+		base.SetOutput(output)
+		if i == 0 {
+			for _, hook := range o.Hooks {
+				base.AddHook(hook)
+			}
+		}
+		backends = append(backends, sinkBackend{Backend: NewLogrusBackend(base), level: sink.Level})
+	}
+	SetLevel(o.Level)
+	backend = backends
+}
+
+func newFormatter(format Format, timestampFormat timex.Format) logrus.Formatter {
+	if format == FormatJson {
+		return &logrus.JSONFormatter{
+			TimestampFormat: timestampFormat.String(),
+		}
 	}
-	base.SetLevel(o.Level)
-	base.SetOutput(o.Output)
-	for _, hook := range o.Hooks {
-		base.AddHook(hook)
+	return &logrus.TextFormatter{
+		TimestampFormat: timestampFormat.String(),
+		FullTimestamp:   true,
 	}
+}
 
-	rootEntry = logrus.NewEntry(base)
+// threshold 返回 logger（为 nil 时是全局级别）在 ctx 下生效的级别，ctx 中的 WithLevel 可以临时提高级别
+func threshold(ctx context.Context, logger *Logger) Level {
+	level := logger.Level()
+	if override, ok := levelFrom(ctx); ok && override > level {
+		level = override
+	}
+	return level
 }
 
+// enabled 判断 logger 是否输出 level 级别的日志。
+// Init 创建的 sinks 按各自的级别判断，固定级别的 Sink 可以输出比 logger 更详细的日志
+func enabled(ctx context.Context, logger *Logger, level Level) bool {
+	if s, ok := backend.(sinks); ok {
+		return s.enabled(ctx, level, threshold(ctx, logger))
+	}
+	return level <= threshold(ctx, logger) && backend.Enabled(ctx, level)
+}
+
+// E 是一条待输出的日志，With 系列方法原地追加字段并返回自身
 type E struct {
-	*logrus.Entry
+	ctx    context.Context
+	logger *Logger
+	fields map[Field]any
+	// sampleKey 不为空时按该 key 采样，为 noSample 时不采样
+	sampleKey string
+}
+
+func newEntry(ctx context.Context, logger *Logger) *E {
+	e := &E{ctx: ctx, logger: logger, fields: make(map[Field]any)}
+	if logger != nil {
+		e.fields[FieldLogger] = logger.name
+	}
+	return e.withTrace(ctx)
 }
 
 func (e *E) WithField(key Field, val any) *E {
-	e.Entry = e.Entry.WithField(key, val)
+	e.fields[key] = val
 	return e
 }
 
 func (e *E) WithFields(fields map[Field]any) *E {
-	e.Entry = e.Entry.WithFields(fields)
+	for k, v := range fields {
+		e.fields[k] = v
+	}
 	return e
 }
 
 func (e *E) WithCaller(caller string) *E {
-	e.Entry = e.Entry.WithField(FieldCaller, caller)
+	e.fields[FieldCaller] = caller
 	return e
 }
 
+// WithError 记录错误，错误链中有 errorx.Wrap、errorx.Errorf 记录的调用栈时同时输出到 FieldStack
 func (e *E) WithError(err error) *E {
-	e.Entry = e.Entry.WithError(err)
+	e.fields[FieldError] = err
+	if stack := errorx.Stack(err); stack != "" {
+		e.fields[FieldStack] = stack
+	}
 	return e
 }
 
 func (e *E) withTrace(ctx context.Context) *E {
-	traceID := trace.TraceIDFrom(ctx)
-	if traceID == "" {
-		return e
+	if traceID := trace.TraceIDFrom(ctx); traceID != "" {
+		e.fields[FieldTraceID] = traceID
+	}
+	if requestID := trace.RequestIDFrom(ctx); requestID != "" {
+		e.fields[FieldRequestID] = requestID
+	}
+	if span, ok := trace.SpanFrom(ctx); ok {
+		e.fields[FieldSpanID] = span.SpanID
+	}
+	if clientIP := trace.ClientIPFrom(ctx); clientIP != "" {
+		e.fields[FieldRemoteIP] = clientIP
+	}
+	if userAgent := trace.UserAgentFrom(ctx); userAgent != "" {
+		e.fields[FieldUA] = userAgent
 	}
-	e.Entry = e.Entry.WithField(FieldTraceID, traceID)
 	return e
 }
 
+// log 输出日志，key 是自动采样时使用的 key（格式化前的消息）。
+// Fatal 级别输出后退出进程，Panic 级别输出后 panic
+func (e *E) log(level Level, key, msg string) {
+	if enabled(e.ctx, e.logger, level) && e.sample(level, key) {
+		fields := e.fields
+		if r := activeRedactor.Load(); r != nil {
+			msg, fields = r.scrub(msg), r.redactFields(fields)
+		}
+		if s, ok := backend.(sinks); ok {
+			s.log(e.ctx, level, threshold(e.ctx, e.logger), msg, fields)
+		} else {
+			backend.Log(e.ctx, level, msg, fields)
+		}
+	}
+	switch level {
+	case LevelFatal:
+		exit(1)
+	case LevelPanic:
+		panic(msg)
+	}
+}
+
+func (e *E) Log(level Level, args ...any) {
+	msg := fmt.Sprint(args...)
+	e.log(level, msg, msg)
+}
+
+func (e *E) Logf(level Level, format string, args ...any) {
+	e.log(level, format, fmt.Sprintf(format, args...))
+}
+
+func (e *E) Trace(args ...any) {
+	e.Log(LevelTrace, args...)
+}
+
+func (e *E) Tracef(format string, args ...any) {
+	e.Logf(LevelTrace, format, args...)
+}
+
+func (e *E) Debug(args ...any) {
+	e.Log(LevelDebug, args...)
+}
+
+func (e *E) Debugf(format string, args ...any) {
+	e.Logf(LevelDebug, format, args...)
+}
+
+func (e *E) Info(args ...any) {
+	e.Log(LevelInfo, args...)
+}
+
+func (e *E) Infof(format string, args ...any) {
+	e.Logf(LevelInfo, format, args...)
+}
+
+func (e *E) Warn(args ...any) {
+	e.Log(LevelWarn, args...)
+}
+
+func (e *E) Warnf(format string, args ...any) {
+	e.Logf(LevelWarn, format, args...)
+}
+
+func (e *E) Error(args ...any) {
+	e.Log(LevelError, args...)
+}
+
+func (e *E) Errorf(format string, args ...any) {
+	e.Logf(LevelError, format, args...)
+}
+
+func (e *E) Fatal(args ...any) {
+	e.Log(LevelFatal, args...)
+}
+
+func (e *E) Fatalf(format string, args ...any) {
+	e.Logf(LevelFatal, format, args...)
+}
+
+func (e *E) Panic(args ...any) {
+	e.Log(LevelPanic, args...)
+}
+
+func (e *E) Panicf(format string, args ...any) {
+	e.Logf(LevelPanic, format, args...)
+}
+
 func WithContext(ctx context.Context) *E {
-	e := E{rootEntry.WithContext(ctx)}
-	return e.withTrace(ctx).WithCaller(defaultCaller())
+	return newEntry(ctx, nil).WithCaller(defaultCaller())
 }
 
 func Info(args ...any) {
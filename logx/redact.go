@@ -0,0 +1,351 @@
+package logx
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const maxRedactDepth = 8
+
+// RedactOptions 配置日志脱敏，作用于消息、字段值以及其中嵌套的 map、slice 和结构体，
+// 与输出格式和 Backend 无关。结构体字段带有 `log:"redact"` 标签时整体遮盖
+type RedactOptions struct {
+	// Fields 是需要整体遮盖的字段名，名称按 "_"、"-"、"." 和驼峰拆分为片段后忽略大小写匹配：
+	// 完全相同或包含完整的片段即匹配（如 token 匹配 access_token、accessToken，不匹配 max_tokens），
+	// 同样作用于 map 的 key、结构体字段，以及字符串中 name=value、"name": "value" 形式的内容
+	Fields []string
+	// Patterns 匹配到的内容会被遮盖
+	Patterns []Pattern
+	// Mask 是遮盖后的内容，默认为 ***
+	Mask string
+}
+
+type Pattern struct {
+	Regexp *regexp.Regexp
+	// Replace 不为空时作为 Regexp.ReplaceAllString 的模板，可以保留部分内容，其中的 ${mask} 替换为 Mask，
+	// 如 "${1}${mask}"
+	Replace string
+	// Match 不为空时只遮盖通过校验的内容，如用 Luhn 校验卡号
+	Match func(s string) bool
+}
+
+var (
+	JWTPattern    = Pattern{Regexp: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)}
+	BearerPattern = Pattern{Regexp: regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/-]+=*`), Replace: "${1}${mask}"}
+	EmailPattern  = Pattern{Regexp: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)}
+	CardPattern   = Pattern{Regexp: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), Match: luhn}
+	// PhonePattern 匹配中国大陆手机号
+	PhonePattern = Pattern{Regexp: regexp.MustCompile(`\b1[3-9]\d{9}\b`)}
+)
+
+func DefaultRedactOptions() *RedactOptions {
+	return &RedactOptions{
+		Fields: []string{
+			"password", "passwd", "pwd", "secret", "token", "authorization", "cookie", "api_key", "private_key",
+		},
+		Patterns: []Pattern{JWTPattern, BearerPattern, EmailPattern, CardPattern, PhonePattern},
+	}
+}
+
+type redactor struct {
+	fields []deniedField
+	// kv 匹配字符串中名称包含 Fields 的 name=value，名称还需要通过 deny 判断
+	kv       *regexp.Regexp
+	patterns []Pattern
+	mask     string
+}
+
+type deniedField struct {
+	segments []string
+	joined   string
+}
+
+var activeRedactor atomic.Pointer[redactor]
+
+func setRedactor(o *RedactOptions) {
+	if o == nil {
+		activeRedactor.Store(nil)
+		return
+	}
+
+	r := &redactor{mask: o.Mask}
+	if r.mask == "" {
+		r.mask = "***"
+	}
+	quoted := make([]string, 0, len(o.Fields))
+	for _, f := range o.Fields {
+		segments := fieldSegments(f)
+		r.fields = append(r.fields, deniedField{segments: segments, joined: strings.Join(segments, "")})
+		for _, seg := range segments {
+			quoted = append(quoted, regexp.QuoteMeta(seg))
+		}
+	}
+	if len(quoted) > 0 {
+		// name=value、name: value 以及 JSON 中的 "name":"value"，值可以带引号或 Bearer、Basic 前缀
+		r.kv = regexp.MustCompile(`(?i)([\w.-]*(?:` + strings.Join(quoted, "|") + `)[\w.-]*)(["']?\s*[:=]\s*)((?:bearer\s+|basic\s+)?(?:"[^"]*"|'[^']*'|[^\s&,;)]+))`)
+	}
+	r.patterns = append(r.patterns, o.Patterns...)
+	for i := range r.patterns {
+		r.patterns[i].Replace = strings.ReplaceAll(r.patterns[i].Replace, "${mask}", r.mask)
+	}
+	activeRedactor.Store(r)
+}
+
+// fieldSegments 把名称按 "_"、"-"、"." 和驼峰拆分为小写的片段，如 X-Api-Key 拆为 x、api、key
+func fieldSegments(name string) []string {
+	var segments []string
+	start := 0
+	for i := 0; i <= len(name); i++ {
+		split := i == len(name) || name[i] == '_' || name[i] == '-' || name[i] == '.'
+		camel := !split && i > start && isUpper(name[i]) && !isUpper(name[i-1])
+		if !split && !camel {
+			continue
+		}
+		if i > start {
+			segments = append(segments, strings.ToLower(name[start:i]))
+		}
+		start = i
+		if split {
+			start = i + 1
+		}
+	}
+	return segments
+}
+
+func isUpper(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
+
+func (r *redactor) deny(name string) bool {
+	segments := fieldSegments(name)
+	joined := strings.Join(segments, "")
+	for _, f := range r.fields {
+		if joined == f.joined || containsSegments(segments, f.segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsSegments 判断 sub 是否是 segments 中连续的一段
+func containsSegments(segments, sub []string) bool {
+	if len(sub) == 0 {
+		return false
+	}
+	for i := 0; i+len(sub) <= len(segments); i++ {
+		if slices.Equal(segments[i:i+len(sub)], sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) scrub(s string) string {
+	if r.kv != nil {
+		s = r.kv.ReplaceAllStringFunc(s, func(m string) string {
+			sub := r.kv.FindStringSubmatch(m)
+			if !r.deny(sub[1]) {
+				return m
+			}
+			return sub[1] + sub[2] + r.mask
+		})
+	}
+	for _, p := range r.patterns {
+		switch {
+		case p.Replace != "":
+			s = p.Regexp.ReplaceAllString(s, p.Replace)
+		case p.Match != nil:
+			s = p.Regexp.ReplaceAllStringFunc(s, func(m string) string {
+				if p.Match(m) {
+					return r.mask
+				}
+				return m
+			})
+		default:
+			s = p.Regexp.ReplaceAllLiteralString(s, r.mask)
+		}
+	}
+	return s
+}
+
+// redactFields 返回脱敏后的新 map，不修改原来的字段
+func (r *redactor) redactFields(fields map[Field]any) map[Field]any {
+	out := make(map[Field]any, len(fields))
+	for k, v := range fields {
+		if r.deny(k) {
+			out[k] = r.mask
+			continue
+		}
+		out[k] = r.value(v, 0)
+	}
+	return out
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func (r *redactor) value(v any, depth int) any {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.scrub(x)
+	case error:
+		return r.scrub(x.Error())
+	}
+	if depth >= maxRedactDepth {
+		return v
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() || rv.Elem().Kind() != reflect.Struct || isMarshaler(rv.Type()) {
+			return v
+		}
+		return r.value(rv.Elem().Interface(), depth)
+	case reflect.Struct:
+		if isMarshaler(rv.Type()) {
+			return v
+		}
+		out := make(map[string]any)
+		r.structFields(rv, out, depth)
+		return out
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		out := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			if r.deny(k) {
+				out[k] = r.mask
+				continue
+			}
+			out[k] = r.value(iter.Value().Interface(), depth+1)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if !needsRedact(rv.Type().Elem()) {
+			return v
+		}
+		out := make([]any, rv.Len())
+		for i := range out {
+			out[i] = r.value(rv.Index(i).Interface(), depth+1)
+		}
+		return out
+	case reflect.String:
+		return r.scrub(rv.String())
+	}
+	return v
+}
+
+// structFields 按 json 标签命名展开结构体，匿名嵌入且没有命名的结构体与 encoding/json 一样平铺
+func (r *redactor) structFields(rv reflect.Value, out map[string]any, depth int) {
+	for _, f := range cachedFields(rv.Type()) {
+		fv := rv.Field(f.index)
+		if f.inline {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			r.structFields(fv, out, depth)
+			continue
+		}
+		if f.redact || r.deny(f.name) {
+			out[f.name] = r.mask
+			continue
+		}
+		out[f.name] = r.value(fv.Interface(), depth+1)
+	}
+}
+
+type structField struct {
+	index  int
+	name   string
+	inline bool
+	redact bool
+}
+
+var structFieldsCache sync.Map
+
+func cachedFields(t reflect.Type) []structField {
+	if v, ok := structFieldsCache.Load(t); ok {
+		return v.([]structField)
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, structField{index: i, inline: true})
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, structField{index: i, name: name, redact: sf.Tag.Get("log") == "redact"})
+	}
+
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+func isMarshaler(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PointerTo(t).Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+// needsRedact 判断 slice 元素是否可能包含需要脱敏的内容，数字等基本类型原样输出
+func needsRedact(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return false
+	}
+	return true
+}
+
+// luhn 校验卡号，忽略空格和 "-"
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n > 0 && sum%10 == 0
+}
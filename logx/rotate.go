@@ -0,0 +1,268 @@
+package logx
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "20060102T150405.000"
+
+type RotateOptions struct {
+	Filename string
+	// MaxSize 是单个文件的最大字节数，为 0 时不按大小滚动
+	MaxSize int64
+	// Interval 是按时间滚动的周期，按 UTC 对齐（如 24h 在 UTC 零点滚动），为 0 时不按时间滚动
+	Interval time.Duration
+	// MaxBackups 是保留的历史文件数量，为 0 时全部保留
+	MaxBackups int
+	// Compress 为 true 时历史文件压缩为 gzip
+	Compress bool
+}
+
+// RotatingFile 是按大小或时间滚动的日志文件，可以被多个 goroutine 并发写入。
+// 历史文件命名为 name-<时间>.ext，压缩和清理在后台进行，Close 会等待其完成
+type RotatingFile struct {
+	RotateOptions
+
+	mu         sync.Mutex
+	file       *os.File
+	size       int64
+	rotateAt   time.Time
+	lastBackup time.Time
+
+	millMu   sync.Mutex
+	millWg   sync.WaitGroup
+	now      func() time.Time
+	openFile func(name string, flag int, perm os.FileMode) (*os.File, error)
+}
+
+func NewRotatingFile(o *RotateOptions) (*RotatingFile, error) {
+	if o.Filename == "" {
+		return nil, errors.New("logx: rotate filename is empty")
+	}
+	f := &RotatingFile{RotateOptions: *o, now: time.Now, openFile: os.OpenFile}
+	if err := os.MkdirAll(filepath.Dir(o.Filename), 0o755); err != nil {
+		return nil, err
+	}
+	file, size, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	f.use(file, size)
+	return f, nil
+}
+
+func (f *RotatingFile) open() (*os.File, int64, error) {
+	file, err := f.openFile(f.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+func (f *RotatingFile) use(file *os.File, size int64) {
+	f.file = file
+	f.size = size
+	if f.Interval > 0 {
+		f.rotateAt = f.now().Truncate(f.Interval).Add(f.Interval)
+	}
+}
+
+// Write implements io.Writer.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	// 滚动失败时继续写当前文件，下次写入时重试，不因为滚动失败丢日志
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "logx: rotate %s: %v\n", f.Filename, err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.MaxSize > 0 && f.size > 0 && f.size+n > f.MaxSize {
+		return true
+	}
+	return f.Interval > 0 && !f.now().Before(f.rotateAt)
+}
+
+// Rotate 立即滚动当前文件
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// rotate 先重命名再打开新文件，成功后才关闭原来的文件。
+// 任何一步失败都保留原来的文件句柄并尽量恢复文件名，保证日志仍然可以写入
+func (f *RotatingFile) rotate() error {
+	backup := f.backupName()
+	if err := os.Rename(f.Filename, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	file, size, err := f.open()
+	if err != nil {
+		if rerr := os.Rename(backup, f.Filename); rerr != nil && !os.IsNotExist(rerr) {
+			err = errors.Join(err, rerr)
+		}
+		return err
+	}
+	if err := f.file.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "logx: close %s: %v\n", backup, err)
+	}
+	f.use(file, size)
+
+	f.millWg.Add(1)
+	go func() {
+		defer f.millWg.Done()
+		f.mill(backup)
+	}()
+	return nil
+}
+
+// backupName 按滚动时间生成历史文件名，同一毫秒内多次滚动时顺延，避免覆盖已有的文件
+func (f *RotatingFile) backupName() string {
+	ext := filepath.Ext(f.Filename)
+	ts := f.now().UTC()
+	if !ts.After(f.lastBackup) {
+		ts = f.lastBackup.Add(time.Millisecond)
+	}
+	for {
+		name := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(f.Filename, ext), ts.Format(backupTimeFormat), ext)
+		if !exists(name) && !exists(name+".gz") {
+			f.lastBackup = ts
+			return name
+		}
+		ts = ts.Add(time.Millisecond)
+	}
+}
+
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// mill 压缩刚滚动出来的文件并清理超出数量的历史文件，失败时写到 stderr，避免递归写日志
+func (f *RotatingFile) mill(backup string) {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	if f.Compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "logx: compress %s: %v\n", backup, err)
+		}
+	}
+	if f.MaxBackups <= 0 {
+		return
+	}
+	backups, err := f.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logx: list backups: %v\n", err)
+		return
+	}
+	for i := 0; i < len(backups)-f.MaxBackups; i++ {
+		if err := os.Remove(backups[i]); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "logx: remove %s: %v\n", backups[i], err)
+		}
+	}
+}
+
+// backups 返回按时间从旧到新排序的历史文件
+func (f *RotatingFile) backups() ([]string, error) {
+	ext := filepath.Ext(f.Filename)
+	prefix := filepath.Base(strings.TrimSuffix(f.Filename, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.Filename))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, ts); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(f.Filename), name))
+	}
+	// 时间格式按字典序即按时间排序
+	sort.Strings(backups)
+	return backups, nil
+}
+
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+// Sync 将已写入的内容刷到磁盘
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close 关闭文件并等待后台的压缩和清理完成，重复调用是安全的
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = errors.Join(f.file.Sync(), f.file.Close())
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.millWg.Wait()
+	return err
+}
@@ -0,0 +1,256 @@
+package logx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile_MaxSize(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(&RotateOptions{Filename: name, MaxSize: 10, MaxBackups: 2})
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+
+	current, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "dddddddd\n", string(current))
+
+	// 只保留最新的兩個歷史文件
+	backups, err := f.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	b0, _ := os.ReadFile(backups[0])
+	b1, _ := os.ReadFile(backups[1])
+	assert.Equal(t, "bbbbbbbb\n", string(b0))
+	assert.Equal(t, "cccccccc\n", string(b1))
+}
+
+func TestRotatingFile_IntervalAndCompress(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(&RotateOptions{Filename: name, Interval: time.Hour, Compress: true})
+	require.NoError(t, err)
+
+	now := time.Now()
+	f.now = func() time.Time { return now }
+	_, err = f.Write([]byte("first\n"))
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	_, err = f.Write([]byte("second\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	backups, err := f.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.True(t, strings.HasSuffix(backups[0], ".log.gz"))
+
+	gz, err := os.Open(backups[0])
+	require.NoError(t, err)
+	defer gz.Close()
+	zr, err := gzip.NewReader(gz)
+	require.NoError(t, err)
+	content, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, "first\n", string(content))
+
+	// 關閉後不能再寫入
+	_, err = f.Write([]byte("closed\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestRotatingFile_Concurrent(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(&RotateOptions{Filename: name, MaxSize: 1 << 10})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, _ = f.Write([]byte("0123456789\n"))
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, f.Close())
+
+	backups, err := f.backups()
+	require.NoError(t, err)
+	total := 0
+	for _, b := range append(backups, name) {
+		content, err := os.ReadFile(b)
+		require.NoError(t, err)
+		total += strings.Count(string(content), "0123456789\n")
+	}
+	assert.Equal(t, 800, total)
+}
+
+func TestInit_Sinks(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	name := filepath.Join(t.TempDir(), "app.log")
+	Init(&Options{
+		Sinks: []Sink{
+			{Format: FormatText, Level: LevelWarn, Output: stdout},
+			{Format: FormatJson, Level: LevelDebug, Rotate: &RotateOptions{Filename: name}},
+		},
+	})
+	t.Cleanup(func() { Init(&Options{}) })
+
+	WithContext(context.Background()).Debug("debug message")
+	WithContext(context.Background()).Warn("warn message")
+	require.NoError(t, Close())
+
+	assert.NotContains(t, stdout.String(), "debug message")
+	assert.Contains(t, stdout.String(), `msg="warn message"`)
+
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+	file := bytes.NewBuffer(content)
+	entries := decodeLines(t, file)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "debug message", entries[0]["msg"])
+	assert.Equal(t, "warn message", entries[1]["msg"])
+}
+
+func TestInit_SinksMixedLevels(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	file := &bytes.Buffer{}
+	Init(&Options{
+		Level: LevelInfo,
+		Sinks: []Sink{
+			{Format: FormatText, Output: stdout},
+			{Format: FormatJson, Level: LevelDebug, Output: file},
+		},
+	})
+	t.Cleanup(func() { Init(&Options{}) })
+
+	// 跟隨全域級別的 Sink 不會因為其他 Sink 是 Debug 而輸出 Debug
+	assert.Equal(t, LevelInfo, GetLevel())
+	WithContext(context.Background()).Debug("debug message")
+	WithContext(context.Background()).Info("info message")
+	assert.NotContains(t, stdout.String(), "debug message")
+	assert.Contains(t, stdout.String(), `msg="info message"`)
+	entries := decodeLines(t, file)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "debug message", entries[0]["msg"])
+	assert.Equal(t, "info message", entries[1]["msg"])
+
+	// Logger 單獨設定的級別只影響跟隨全域級別的 Sink
+	stdout.Reset()
+	file.Reset()
+	l := Named("sink-mixed")
+	l.SetLevel(LevelDebug)
+	t.Cleanup(l.ResetLevel)
+	l.WithContext(context.Background()).Trace("trace message")
+	l.WithContext(context.Background()).Debug("named debug")
+	assert.Contains(t, stdout.String(), `msg="named debug"`)
+	assert.NotContains(t, stdout.String(), "trace message")
+	entries = decodeLines(t, file)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "named debug", entries[0]["msg"])
+
+	// 固定 Warn 的 Sink 不輸出 Info
+	stdout.Reset()
+	file.Reset()
+	Init(&Options{
+		Level: LevelDebug,
+		Sinks: []Sink{
+			{Format: FormatText, Level: LevelWarn, Output: stdout},
+			{Format: FormatJson, Output: file},
+		},
+	})
+	WithContext(context.Background()).Info("info message")
+	assert.Empty(t, stdout.String())
+	entries = decodeLines(t, file)
+	require.Len(t, entries, 1)
+}
+
+func TestRotatingFile_RotateFailure(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(&RotateOptions{Filename: name, MaxSize: 10})
+	require.NoError(t, err)
+
+	// 打開新文件失敗時保留原來的文件繼續寫入
+	openErr := errors.New("too many open files")
+	f.openFile = func(string, int, os.FileMode) (*os.File, error) { return nil, openErr }
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	assert.ErrorIs(t, f.Rotate(), openErr)
+	current, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "aaaaaaaa\nbbbbbbbb\n", string(current))
+	backups, err := f.backups()
+	require.NoError(t, err)
+	assert.Empty(t, backups)
+
+	// 恢復後下一次寫入正常滾動
+	f.openFile = os.OpenFile
+	_, err = f.Write([]byte("cccccccc\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	current, err = os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "cccccccc\n", string(current))
+	backups, err = f.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	content, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	assert.Equal(t, "aaaaaaaa\nbbbbbbbb\n", string(content))
+}
+
+func TestInit_SinksWithLevel(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	file := &bytes.Buffer{}
+	Init(&Options{
+		Sinks: []Sink{
+			{Format: FormatText, Output: stdout},
+			{Format: FormatJson, Level: LevelWarn, Output: file},
+		},
+	})
+	t.Cleanup(func() { Init(&Options{}) })
+
+	// WithLevel 同時提高跟隨全域和固定級別的 Sink
+	ctx := WithLevel(context.Background(), LevelDebug)
+	WithContext(ctx).Debug("debug message")
+	WithContext(ctx).Trace("trace message")
+	assert.Contains(t, stdout.String(), `msg="debug message"`)
+	assert.NotContains(t, stdout.String(), "trace message")
+	entries := decodeLines(t, file)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "debug message", entries[0]["msg"])
+
+	// 不會降低固定級別
+	file.Reset()
+	WithContext(WithLevel(context.Background(), LevelError)).Warn("warn message")
+	require.Len(t, decodeLines(t, file), 1)
+}
@@ -0,0 +1,151 @@
+package logx
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const noSample = "\x00"
+
+type SamplingOptions struct {
+	// Interval 是采样周期，每个周期重新计数并输出上个周期丢弃的条数，默认 1s
+	Interval time.Duration
+	// First 是每个 key 每个周期内完整输出的条数，默认 10
+	First int
+	// Thereafter 是超过 First 后每多少条输出一条，为 0 时全部丢弃
+	Thereafter int
+	// Level 不为 0 时，该级别及更严重的日志按格式化前的消息自动采样；Fatal 和 Panic 始终输出
+	Level Level
+}
+
+func (o *SamplingOptions) normalize() {
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	if o.First <= 0 {
+		o.First = 10
+	}
+}
+
+type sampleCount struct {
+	n          int
+	suppressed int
+}
+
+type sampler struct {
+	SamplingOptions
+
+	mu     sync.Mutex
+	counts map[string]*sampleCount
+
+	start sync.Once
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+var activeSampler atomic.Pointer[sampler]
+
+// setSampler 替换全局采样器，o 为空时使用默认配置，只对 Sampled 的日志生效
+func setSampler(o *SamplingOptions) {
+	var opts SamplingOptions
+	if o != nil {
+		opts = *o
+	}
+	opts.normalize()
+	activeSampler.Store(&sampler{
+		SamplingOptions: opts,
+		counts:          make(map[string]*sampleCount),
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+	})
+}
+
+// Sampled 按 key 对这条日志采样，用于可能在循环中大量重复输出的日志
+func (e *E) Sampled(key string) *E {
+	e.sampleKey = key
+	return e
+}
+
+func (e *E) sample(level Level, key string) bool {
+	s := activeSampler.Load()
+	if s == nil || e.sampleKey == noSample || level <= LevelFatal {
+		return true
+	}
+	if e.sampleKey != "" {
+		return s.allow(e.sampleKey)
+	}
+	if s.Level != 0 && level <= s.Level {
+		return s.allow(level.String() + ":" + key)
+	}
+	return true
+}
+
+func (s *sampler) allow(key string) bool {
+	s.start.Do(func() { go s.run() })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.counts[key]
+	if !ok {
+		c = &sampleCount{}
+		s.counts[key] = c
+	}
+	c.n++
+	if c.n <= s.First || (s.Thereafter > 0 && (c.n-s.First)%s.Thereafter == 0) {
+		return true
+	}
+	c.suppressed++
+	return false
+}
+
+func (s *sampler) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush 开始新的周期，并为上个周期有丢弃的 key 各输出一条汇总
+func (s *sampler) flush() {
+	s.mu.Lock()
+	counts := s.counts
+	s.counts = make(map[string]*sampleCount, len(counts))
+	s.mu.Unlock()
+
+	keys := make([]string, 0, len(counts))
+	for k, c := range counts {
+		if c.suppressed > 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e := newEntry(context.Background(), nil)
+		e.sampleKey = noSample
+		e.WithField(FieldSampleKey, k).
+			WithField(FieldSuppressed, counts[k].suppressed).
+			Warn("log entries suppressed")
+	}
+}
+
+// close 停止后台的定时汇总，并输出当前周期丢弃的条数
+func (s *sampler) close() {
+	started := true
+	s.start.Do(func() { started = false })
+	if !started {
+		return
+	}
+	close(s.stop)
+	<-s.done
+}
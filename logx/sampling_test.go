@@ -0,0 +1,120 @@
+package logx
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampling_Auto(t *testing.T) {
+	buf := &bytes.Buffer{}
+	Init(&Options{Format: FormatJson, Output: buf, Sampling: &SamplingOptions{
+		Interval:   time.Hour,
+		First:      2,
+		Thereafter: 3,
+		Level:      LevelWarn,
+	}})
+	t.Cleanup(func() { Init(&Options{}) })
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		WithContext(ctx).Errorf("connect failed: attempt %d", i)
+		// 低於 Level 的日誌不採樣
+		WithContext(ctx).Info("info")
+	}
+
+	var errs, infos int
+	for _, entry := range decodeLines(t, buf) {
+		switch entry["msg"] {
+		case "info":
+			infos++
+		default:
+			errs++
+		}
+	}
+	assert.Equal(t, 10, infos)
+	// 前 2 條，之後第 5、8 條
+	assert.Equal(t, 4, errs)
+	buf.Reset()
+
+	activeSampler.Load().flush()
+	entries := decodeLines(t, buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "log entries suppressed", entries[0]["msg"])
+	assert.Equal(t, "error:connect failed: attempt %d", entries[0][FieldSampleKey])
+	assert.Equal(t, float64(6), entries[0][FieldSuppressed])
+	buf.Reset()
+
+	// 新的週期重新計數
+	WithContext(ctx).Errorf("connect failed: attempt %d", 11)
+	assert.Len(t, decodeLines(t, buf), 1)
+}
+
+func TestSampling_Sampled(t *testing.T) {
+	buf := &bytes.Buffer{}
+	Init(&Options{Format: FormatJson, Output: buf})
+	t.Cleanup(func() { Init(&Options{}) })
+
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		WithContext(ctx).Sampled("poll").Infof("poll %d", i)
+		WithContext(ctx).Warn("not sampled")
+	}
+
+	var polls, warns int
+	for _, entry := range decodeLines(t, buf) {
+		if entry["msg"] == "not sampled" {
+			warns++
+		} else {
+			polls++
+		}
+	}
+	// 默認每個週期輸出前 10 條
+	assert.Equal(t, 10, polls)
+	assert.Equal(t, 20, warns)
+	buf.Reset()
+
+	// Close 時輸出當前週期丟棄的條數
+	require.NoError(t, Close())
+	entries := decodeLines(t, buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "poll", entries[0][FieldSampleKey])
+	assert.Equal(t, float64(10), entries[0][FieldSuppressed])
+}
+
+func TestSampling_Ticker(t *testing.T) {
+	w := &lockedWriter{}
+	Init(&Options{Format: FormatJson, Output: w, Sampling: &SamplingOptions{Interval: 20 * time.Millisecond, First: 1}})
+	t.Cleanup(func() { Init(&Options{}) })
+
+	for i := 0; i < 3; i++ {
+		WithContext(context.Background()).Sampled("tick").Info("tick")
+	}
+	assert.Eventually(t, func() bool {
+		return w.contains("log entries suppressed")
+	}, time.Second, 10*time.Millisecond)
+}
+
+// lockedWriter 允許在後台寫入日誌時並發讀取內容
+type lockedWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *lockedWriter) contains(s string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return strings.Contains(w.buf.String(), s)
+}
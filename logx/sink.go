@@ -0,0 +1,91 @@
+package logx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Sink 是一个输出目标，多个 Sink 可以使用不同的格式和级别，例如 stdout 输出 text，文件输出 json
+type Sink struct {
+	// Format 为空时使用 Options.Format
+	Format Format
+	// Level 是该输出目标固定的级别，不受全局和 Logger 级别影响；为 0 时跟随全局或 Logger 的级别（SetLevel）
+	Level  Level
+	Output io.Writer
+	// Rotate 不为空时输出到 RotatingFile，忽略 Output
+	Rotate *RotateOptions
+}
+
+// sinkBackend 是一个 Sink 对应的 Backend，level 为 0 时跟随全局或 Logger 的级别，否则按 level 过滤。
+// 固定级别同样可以被 WithLevel 临时提高
+type sinkBackend struct {
+	Backend
+	level Level
+}
+
+func (s sinkBackend) enabled(ctx context.Context, level, threshold Level) bool {
+	if s.level != 0 {
+		threshold = s.level
+		if override, ok := levelFrom(ctx); ok && override > threshold {
+			threshold = override
+		}
+	}
+	return level <= threshold && s.Enabled(ctx, level)
+}
+
+// sinks 是 Init 根据 Options.Sinks 创建的 Backend。
+// 每个 Sink 单独判断级别：固定级别的 Sink 不受全局级别影响，跟随全局级别的 Sink 也不会因为其他 Sink 更详细的级别而多输出
+type sinks []sinkBackend
+
+// Enabled implements Backend.
+func (m sinks) Enabled(ctx context.Context, level Level) bool {
+	return m.enabled(ctx, level, GetLevel())
+}
+
+// Log implements Backend.
+func (m sinks) Log(ctx context.Context, level Level, msg string, fields map[Field]any) {
+	m.log(ctx, level, GetLevel(), msg, fields)
+}
+
+// enabled 判断是否有 Sink 输出 level 级别的日志，threshold 是跟随全局的 Sink 使用的级别
+func (m sinks) enabled(ctx context.Context, level, threshold Level) bool {
+	for _, s := range m {
+		if s.enabled(ctx, level, threshold) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m sinks) log(ctx context.Context, level, threshold Level, msg string, fields map[Field]any) {
+	for _, s := range m {
+		if s.enabled(ctx, level, threshold) {
+			s.Log(ctx, level, msg, fields)
+		}
+	}
+}
+
+var (
+	closersMu sync.Mutex
+	closers   []io.Closer
+)
+
+// Close 输出采样丢弃的条数并关闭 Init 打开的文件，应在进程退出前调用，保证日志全部落盘
+func Close() error {
+	if s := activeSampler.Swap(nil); s != nil {
+		s.close()
+	}
+
+	closersMu.Lock()
+	cs := closers
+	closers = nil
+	closersMu.Unlock()
+
+	var errs []error
+	for _, c := range cs {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,165 @@
+package logx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// NewSlogBackend 把日志写到 slog.Handler，字段按名称排序后作为 attr 输出
+func NewSlogBackend(h slog.Handler) Backend {
+	return &slogBackend{handler: h}
+}
+
+type slogBackend struct {
+	handler slog.Handler
+}
+
+// Enabled implements Backend.
+func (b *slogBackend) Enabled(ctx context.Context, level Level) bool {
+	return b.handler.Enabled(ctx, toSlogLevel(level))
+}
+
+// Log implements Backend.
+func (b *slogBackend) Log(ctx context.Context, level Level, msg string, fields map[Field]any) {
+	r := slog.NewRecord(time.Now(), toSlogLevel(level), msg, 0)
+
+	keys := make([]Field, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := fields[k]
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		r.AddAttrs(slog.Any(k, v))
+	}
+
+	_ = b.handler.Handle(ctx, r)
+}
+
+// slog 没有 trace、fatal 和 panic 级别，按间隔 4 向两侧扩展
+func toSlogLevel(level Level) slog.Level {
+	switch level {
+	case LevelTrace:
+		return slog.LevelDebug - 4
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	case LevelFatal:
+		return slog.LevelError + 4
+	default:
+		return slog.LevelError + 8
+	}
+}
+
+func fromSlogLevel(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return LevelTrace
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	default:
+		// 第三方库的日志不应该导致退出或 panic
+		return LevelError
+	}
+}
+
+// NewSlogHandler 返回经由 logx 输出的 slog.Handler，使第三方的 slog 日志与 logx 格式一致，
+// 并带上 ctx 中的 trace_id 等字段
+//
+//	slog.SetDefault(slog.New(logx.NewSlogHandler()))
+func NewSlogHandler() slog.Handler {
+	return &slogHandler{}
+}
+
+type slogHandler struct {
+	attrs  []slog.Attr
+	groups []string
+}
+
+// Enabled implements slog.Handler.
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return enabled(ctx, nil, fromSlogLevel(level))
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	e := newEntry(ctx, nil)
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		e.WithCaller(fmt.Sprintf("%s:%d", frame.File, frame.Line))
+	}
+
+	prefix := ""
+	for _, g := range h.groups {
+		prefix += g + "."
+	}
+	for _, a := range h.attrs {
+		addAttr(e.fields, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(e.fields, prefix, a)
+		return true
+	})
+
+	e.log(fromSlogLevel(r.Level), r.Message, r.Message)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	prefix := ""
+	for _, g := range h.groups {
+		prefix += g + "."
+	}
+	nh := &slogHandler{groups: h.groups, attrs: h.attrs[:len(h.attrs):len(h.attrs)]}
+	for _, a := range attrs {
+		a.Key = prefix + a.Key
+		nh.attrs = append(nh.attrs, a)
+	}
+	return nh
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{attrs: h.attrs, groups: append(h.groups[:len(h.groups):len(h.groups)], name)}
+}
+
+// addAttr 把 group 展开为 "group.key" 形式的字段
+func addAttr(fields map[Field]any, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(fields, prefix, ga)
+		}
+		return
+	}
+	fields[prefix+a.Key] = a.Value.Any()
+}
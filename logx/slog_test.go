@@ -0,0 +1,83 @@
+package logx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/irvingos/go-tools/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestSlogBackend(t *testing.T) {
+	buf := &bytes.Buffer{}
+	Init(&Options{Backend: NewSlogBackend(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))})
+	t.Cleanup(func() { Init(&Options{}) })
+
+	ctx := trace.WithTraceID(context.Background(), "trace-1")
+	WithContext(ctx).WithField(FieldAttempt, 2).WithError(errors.New("boom")).Warn("retry")
+	// 低於 Options.Level 的日誌不輸出
+	WithContext(ctx).Debug("debug")
+
+	entries := decodeLines(t, buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "WARN", entries[0]["level"])
+	assert.Equal(t, "retry", entries[0]["msg"])
+	assert.Equal(t, "trace-1", entries[0][FieldTraceID])
+	assert.Equal(t, float64(2), entries[0][FieldAttempt])
+	assert.Equal(t, "boom", entries[0][FieldError])
+	assert.Contains(t, entries[0][FieldCaller], "slog_test.go")
+}
+
+func TestSlogHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	Init(&Options{Format: FormatJson, Output: buf})
+	t.Cleanup(func() { Init(&Options{}) })
+
+	logger := slog.New(NewSlogHandler()).With("component", "worker").WithGroup("job")
+	ctx := trace.WithTraceID(context.Background(), "trace-2")
+	logger.InfoContext(ctx, "done", "id", 7, slog.Group("result", "rows", 3))
+	logger.DebugContext(ctx, "hidden")
+
+	entries := decodeLines(t, buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "info", entries[0]["level"])
+	assert.Equal(t, "done", entries[0]["msg"])
+	assert.Equal(t, "trace-2", entries[0][FieldTraceID])
+	assert.Equal(t, "worker", entries[0]["component"])
+	assert.Equal(t, float64(7), entries[0]["job.id"])
+	assert.Equal(t, float64(3), entries[0]["job.result.rows"])
+	assert.Contains(t, entries[0][FieldCaller], "slog_test.go")
+}
+
+func TestE_FatalAndPanic(t *testing.T) {
+	Init(&Options{Backend: NewSlogBackend(slog.NewJSONHandler(&bytes.Buffer{}, nil))})
+	t.Cleanup(func() { Init(&Options{}) })
+
+	code := -1
+	prev := exit
+	exit = func(c int) { code = c }
+	t.Cleanup(func() { exit = prev })
+
+	WithContext(context.Background()).Fatal("fatal")
+	assert.Equal(t, 1, code)
+	assert.PanicsWithValue(t, "panic", func() { WithContext(context.Background()).Panic("panic") })
+}
@@ -0,0 +1,24 @@
+package metrics
+
+import "runtime"
+
+// 内置指标，由 middleware、gormx、logx 和 graceful 中的埋点更新
+var (
+	HTTPRequestsTotal = NewCounter("http_requests_total",
+		"Total number of HTTP requests.", "method", "route", "status", "code")
+	HTTPRequestDuration = NewHistogram("http_request_duration_seconds",
+		"HTTP request latency in seconds.", nil, "method", "route", "status", "code")
+	HTTPPanicsRecovered = NewCounter("http_panics_recovered_total",
+		"Total number of panics recovered by RecoveryMiddleware.", "route")
+
+	DBQueryDuration = NewHistogram("db_query_duration_seconds",
+		"Database statement latency in seconds.", nil, "operation", "table")
+	DBSlowQueriesTotal = NewCounter("db_slow_queries_total",
+		"Total number of statements slower than DBLoggerOptions.SlowSQLThreshold.")
+
+	InFlight = NewGauge("graceful_in_flight",
+		"Number of in-flight tasks tracked by graceful.RuntimeManager.", "name")
+
+	Goroutines = NewGaugeFunc("go_goroutines",
+		"Number of goroutines that currently exist.", func() float64 { return float64(runtime.NumGoroutine()) })
+)
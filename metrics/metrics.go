@@ -0,0 +1,254 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// labelSep 用于拼接 label 值作为 series 的 key，不会出现在正常的 label 值中
+const labelSep = "\xff"
+
+// DefBuckets 是默认的 histogram 桶，单位为秒，适用于请求和 SQL 耗时
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// desc 是指标的元信息，以及按 label 值保存的所有 series
+type desc[S any] struct {
+	name   string
+	help   string
+	typ    metricType
+	labels []string
+
+	mu     sync.RWMutex
+	series map[string]*S
+	newS   func() *S
+}
+
+func newDesc[S any](name, help string, typ metricType, labels []string, newS func() *S) *desc[S] {
+	return &desc[S]{name: name, help: help, typ: typ, labels: labels, series: make(map[string]*S), newS: newS}
+}
+
+func (d *desc[S]) Name() string {
+	return d.name
+}
+
+func (d *desc[S]) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s: expected %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, labelSep)
+}
+
+// lookup 返回 label 值对应的 series，不存在时不创建，读取时使用，避免产生空的 series
+func (d *desc[S]) lookup(values []string) (*S, bool) {
+	return d.find(d.key(values))
+}
+
+func (d *desc[S]) find(key string) (*S, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	s, ok := d.series[key]
+	return s, ok
+}
+
+// get 返回 label 值对应的 series，不存在时创建。label 值的数量必须与定义一致，否则 panic
+func (d *desc[S]) get(values []string) *S {
+	key := d.key(values)
+	s, ok := d.find(key)
+	if ok {
+		return s
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if s, ok = d.series[key]; !ok {
+		s = d.newS()
+		d.series[key] = s
+	}
+	return s
+}
+
+// each 按 label 值排序遍历 series，保证输出稳定
+func (d *desc[S]) each(fn func(values []string, s *S)) {
+	d.mu.RLock()
+	keys := make([]string, 0, len(d.series))
+	for k := range d.series {
+		keys = append(keys, k)
+	}
+	series := make(map[string]*S, len(d.series))
+	for k, s := range d.series {
+		series[k] = s
+	}
+	d.mu.RUnlock()
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		var values []string
+		if len(d.labels) > 0 {
+			values = strings.Split(k, labelSep)
+		}
+		fn(values, series[k])
+	}
+}
+
+// atomicFloat 以 float64 的位模式保存在 uint64 中，支持并发累加
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) Load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+func (f *atomicFloat) Store(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) Add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Counter 是只增不减的计数，调用时按定义的顺序传入 label 值
+type Counter struct {
+	*desc[atomicFloat]
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.get(labelValues).Add(1)
+}
+
+// Add 增加 v，v 为负数时 panic
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("metrics: " + c.name + ": counter cannot decrease")
+	}
+	c.get(labelValues).Add(v)
+}
+
+// Value 返回当前值，series 不存在时返回 0
+func (c *Counter) Value(labelValues ...string) float64 {
+	if s, ok := c.lookup(labelValues); ok {
+		return s.Load()
+	}
+	return 0
+}
+
+// Gauge 是可增可减的当前值
+type Gauge struct {
+	*desc[atomicFloat]
+}
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.get(labelValues).Store(v)
+}
+
+func (g *Gauge) Inc(labelValues ...string) {
+	g.get(labelValues).Add(1)
+}
+
+func (g *Gauge) Dec(labelValues ...string) {
+	g.get(labelValues).Add(-1)
+}
+
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.get(labelValues).Add(v)
+}
+
+// Value 返回当前值，series 不存在时返回 0
+func (g *Gauge) Value(labelValues ...string) float64 {
+	if s, ok := g.lookup(labelValues); ok {
+		return s.Load()
+	}
+	return 0
+}
+
+// GaugeFunc 在输出时调用 fn 取值，适用于 goroutine 数量等由外部维护的值
+type GaugeFunc struct {
+	name string
+	help string
+	fn   func() float64
+}
+
+func (g *GaugeFunc) Name() string {
+	return g.name
+}
+
+type histogramSeries struct {
+	mu      sync.Mutex
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// Histogram 统计观测值的分布，桶的上界为 buckets，另有一个 +Inf 桶
+type Histogram struct {
+	*desc[histogramSeries]
+	buckets []float64
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	s := h.get(labelValues)
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i < len(s.buckets) {
+		s.buckets[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+// Count 返回观测次数，series 不存在时返回 0
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	s, ok := h.lookup(labelValues)
+	if !ok {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// Sum 返回观测值之和，series 不存在时返回 0
+func (h *Histogram) Sum(labelValues ...string) float64 {
+	s, ok := h.lookup(labelValues)
+	if !ok {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sum
+}
+
+func newHistogram(name, help string, buckets []float64, labels []string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = slices.Clone(buckets)
+	sort.Float64s(buckets)
+	n := len(buckets)
+	return &Histogram{
+		desc: newDesc(name, help, typeHistogram, labels, func() *histogramSeries {
+			return &histogramSeries{buckets: make([]uint64, n)}
+		}),
+		buckets: buckets,
+	}
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("requests_total", "Total requests.", "method", "path")
+	g := r.NewGauge("temperature", "Current temperature.")
+	h := r.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1}, "op")
+	r.NewGaugeFunc("answer", "", func() float64 { return 42 })
+
+	c.Inc("GET", "/a")
+	c.Add(2, "GET", "/a")
+	c.Inc("POST", `/b"\`+"\n")
+	g.Set(1.5)
+	g.Dec()
+	h.Observe(0.05, "read")
+	h.Observe(0.5, "read")
+	h.Observe(3, "read")
+
+	var b strings.Builder
+	_, err := r.WriteTo(&b)
+	assert.NoError(t, err)
+
+	// 按名稱排序，histogram 的桶是累計的，label 值需要轉義
+	assert.Equal(t, `# TYPE answer gauge
+answer 42
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="read",le="0.1"} 1
+latency_seconds_bucket{op="read",le="1"} 2
+latency_seconds_bucket{op="read",le="+Inf"} 3
+latency_seconds_sum{op="read"} 3.55
+latency_seconds_count{op="read"} 3
+# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{method="GET",path="/a"} 3
+requests_total{method="POST",path="/b\"\\\n"} 1
+# HELP temperature Current temperature.
+# TYPE temperature gauge
+temperature 0.5
+`, b.String())
+
+	assert.Equal(t, float64(3), c.Value("GET", "/a"))
+	assert.Equal(t, uint64(3), h.Count("read"))
+	assert.InDelta(t, 3.55, h.Sum("read"), 1e-9)
+}
+
+func TestRead_NoSeries(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("c_total", "", "a")
+	g := r.NewGauge("g", "", "a")
+	h := r.NewHistogram("h_seconds", "", nil, "a")
+
+	// 讀取不存在的 series 返回 0，不會產生空的 series
+	assert.Zero(t, c.Value("x"))
+	assert.Zero(t, g.Value("x"))
+	assert.Zero(t, h.Count("x"))
+	assert.Zero(t, h.Sum("x"))
+
+	var b strings.Builder
+	_, err := r.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.NotContains(t, b.String(), `a="x"`)
+	assert.Panics(t, func() { c.Value() })
+}
+
+func TestRegistry_Invalid(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("c_total", "", "a")
+
+	assert.Panics(t, func() { r.NewGauge("c_total", "") })
+	assert.Panics(t, func() { c.Inc() })
+	assert.Panics(t, func() { c.Add(-1, "x") })
+}
+
+func TestCounter_Concurrent(t *testing.T) {
+	c := NewRegistry().NewCounter("c_total", "", "a")
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				c.Add(0.5, "x")
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, float64(2500), c.Value("x"))
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("hits_total", "Hits.").Inc()
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.GET("/metrics", r.Handler())
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, contentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "hits_total 1\n")
+}
@@ -0,0 +1,233 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Collector 是可以注册到 Registry 的指标，即 Counter、Gauge、GaugeFunc 和 Histogram
+type Collector interface {
+	Name() string
+	write(w *bufio.Writer)
+}
+
+// Registry 保存一组指标，按 Prometheus 文本格式输出
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]Collector)}
+}
+
+// Default 是全局的 Registry，包级别的构造函数和内置指标都注册在这里
+var Default = NewRegistry()
+
+// Register 注册指标，名称重复时返回错误
+func (r *Registry) Register(c Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collectors[c.Name()]; ok {
+		return fmt.Errorf("metrics: duplicate metric %q", c.Name())
+	}
+	r.collectors[c.Name()] = c
+	return nil
+}
+
+func (r *Registry) MustRegister(cs ...Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: newDesc(name, help, typeCounter, labels, newAtomicFloat)}
+	r.MustRegister(c)
+	return c
+}
+
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{desc: newDesc(name, help, typeGauge, labels, newAtomicFloat)}
+	r.MustRegister(g)
+	return g
+}
+
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, fn: fn}
+	r.MustRegister(g)
+	return g
+}
+
+// NewHistogram 创建 histogram，buckets 为空时使用 DefBuckets
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := newHistogram(name, help, buckets, labels)
+	r.MustRegister(h)
+	return h
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	return Default.NewCounter(name, help, labels...)
+}
+
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return Default.NewGauge(name, help, labels...)
+}
+
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	return Default.NewGaugeFunc(name, help, fn)
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return Default.NewHistogram(name, help, buckets, labels...)
+}
+
+func newAtomicFloat() *atomicFloat {
+	return &atomicFloat{}
+}
+
+// WriteTo 按名称顺序输出所有指标
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	collectors := make([]Collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.RUnlock()
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].Name() < collectors[j].Name() })
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler 返回输出指标的 gin handler，一般挂在 /metrics
+//
+//	r.GET("/metrics", metrics.Default.Handler())
+func (r *Registry) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", contentType)
+		c.Status(http.StatusOK)
+		_, _ = r.WriteTo(c.Writer)
+	}
+}
+
+// Handler 输出 Default 中的指标
+func Handler() gin.HandlerFunc {
+	return Default.Handler()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func writeHeader(w *bufio.Writer, name, help string, typ metricType) {
+	if help != "" {
+		w.WriteString("# HELP " + name + " " + helpEscaper.Replace(help) + "\n")
+	}
+	w.WriteString("# TYPE " + name + " " + string(typ) + "\n")
+}
+
+// writeSample 输出一行样本，extra 是 histogram 的 le 等附加 label
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraName, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(l + `="` + labelEscaper.Replace(values[i]) + `"`)
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraName + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	writeHeader(w, c.name, c.help, c.typ)
+	c.each(func(values []string, s *atomicFloat) {
+		writeSample(w, c.name, c.labels, values, "", "", s.Load())
+	})
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	writeHeader(w, g.name, g.help, g.typ)
+	g.each(func(values []string, s *atomicFloat) {
+		writeSample(w, g.name, g.labels, values, "", "", s.Load())
+	})
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	writeHeader(w, g.name, g.help, typeGauge)
+	writeSample(w, g.name, nil, nil, "", "", g.fn())
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	writeHeader(w, h.name, h.help, h.typ)
+	h.each(func(values []string, s *histogramSeries) {
+		s.mu.Lock()
+		buckets := append([]uint64(nil), s.buckets...)
+		count, sum := s.count, s.sum
+		s.mu.Unlock()
+
+		// Prometheus 的桶是累计的
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += buckets[i]
+			writeSample(w, h.name+"_bucket", h.labels, values, "le", formatFloat(upper), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, values, "le", "+Inf", float64(count))
+		writeSample(w, h.name+"_sum", h.labels, values, "", "", sum)
+		writeSample(w, h.name+"_count", h.labels, values, "", "", float64(count))
+	})
+}
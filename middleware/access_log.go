@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/errorx"
+	"github.com/irvingos/go-tools/logx"
+	"github.com/irvingos/go-tools/resp"
+	"github.com/irvingos/go-tools/trace"
+)
+
+type AccessLogOptions struct {
+	// SkipPaths 中的路径不记录，同时匹配请求路径和路由模板
+	SkipPaths []string
+	// SampleRates 按路径采样，值为 0~1 之间的记录比例，适用于健康检查等高频路径
+	SampleRates map[string]float64
+	// LogHeaders 为 true 时记录请求头，HideHeaders 中的请求头会被打码
+	LogHeaders  bool
+	HideHeaders []string
+	// Level 根据 HTTP 状态码和业务码决定日志级别
+	Level func(status, code int) logx.Level
+}
+
+func (o *AccessLogOptions) normalize() {
+	if o.Level == nil {
+		o.Level = defaultAccessLogLevel
+	}
+}
+
+func defaultAccessLogLevel(status, code int) logx.Level {
+	switch {
+	case status >= http.StatusInternalServerError || code == errorx.ErrInternal.Code():
+		return logx.LevelError
+	case status >= http.StatusBadRequest || code != 0:
+		return logx.LevelWarn
+	default:
+		return logx.LevelInfo
+	}
+}
+
+// httpLogger 的级别可以通过 logx.Named("http").SetLevel 单独调整
+var httpLogger = logx.Named("http")
+
+// AccessLogMiddleware 每个请求输出一条访问日志，需要放在 RecoveryMiddleware 之前才能记录 panic 的请求
+func AccessLogMiddleware(o *AccessLogOptions) gin.HandlerFunc {
+	var opts AccessLogOptions
+	if o != nil {
+		opts = *o
+	}
+	opts.normalize()
+
+	skip := make(map[string]struct{}, len(opts.SkipPaths))
+	for _, p := range opts.SkipPaths {
+		skip[p] = struct{}{}
+	}
+
+	return func(ctx *gin.Context) {
+		path := ctx.Request.URL.Path
+		if _, ok := skip[path]; ok {
+			ctx.Next()
+			return
+		}
+		if _, ok := skip[ctx.FullPath()]; ok {
+			ctx.Next()
+			return
+		}
+		if rate, ok := opts.SampleRates[path]; ok && rand.Float64() >= rate {
+			ctx.Next()
+			return
+		}
+
+		start := time.Now()
+		ctx.Next()
+		latency := time.Since(start)
+
+		status := ctx.Writer.Status()
+		code := resp.CodeFrom(ctx)
+		// 优先使用 RequestContextMiddleware 按可信代理解析出的地址，与 trace、其他日志保持一致
+		clientIP := trace.ClientIPFrom(ctx)
+		if clientIP == "" {
+			clientIP = ctx.ClientIP()
+		}
+
+		entry := httpLogger.WithContext(ctx).
+			WithField(logx.FieldLatency, fmt.Sprintf("%.3fms", float64(latency.Nanoseconds())/1e6)).
+			WithField(logx.FieldStatus, status).
+			WithField(logx.FieldCode, code).
+			WithField(logx.FieldRemoteIP, clientIP).
+			WithField(logx.FieldMethod, ctx.Request.Method).
+			WithField(logx.FieldPath, path).
+			WithField(logx.FieldQuery, ctx.Request.URL.RawQuery).
+			WithField(logx.FieldUA, ctx.Request.UserAgent())
+		if opts.LogHeaders {
+			entry = entry.WithField(logx.FieldHeaders, filterHeaders(ctx.Request.Header, opts.HideHeaders))
+		}
+		if len(ctx.Errors) > 0 {
+			entry = entry.WithField(logx.FieldError, ctx.Errors.String())
+			if stack := errorx.Stack(ctx.Errors.Last().Err); stack != "" {
+				entry = entry.WithField(logx.FieldStack, stack)
+			}
+		}
+
+		entry.Log(opts.Level(status, code), "access")
+	}
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/errorx"
+	"github.com/irvingos/go-tools/logx"
+	"github.com/irvingos/go-tools/resp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAccessLog(t *testing.T, o *AccessLogOptions) (*gin.Engine, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	logx.Init(&logx.Options{Format: logx.FormatJson, Output: buf})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(AccessLogMiddleware(o), RecoveryMiddleware(nil))
+	r.GET("/ok", func(c *gin.Context) {
+		resp.OK(c, nil)
+	})
+	r.GET("/users/:id", func(c *gin.Context) {
+		resp.Error(c, errorx.ErrNotFound)
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		panic("test panic")
+	})
+	r.GET("/healthz", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r, buf
+}
+
+func accessEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry["msg"] == "access" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestAccessLogMiddleware_Fields(t *testing.T) {
+	r, buf := setupAccessLog(t, &AccessLogOptions{
+		LogHeaders:  true,
+		HideHeaders: []string{"Authorization"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ok?a=1", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("Authorization", "Bearer secret")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := accessEntries(t, buf)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, float64(http.StatusOK), entry[logx.FieldStatus])
+	assert.Equal(t, float64(0), entry[logx.FieldCode])
+	assert.Equal(t, http.MethodGet, entry[logx.FieldMethod])
+	assert.Equal(t, "/ok", entry[logx.FieldPath])
+	assert.Equal(t, "a=1", entry[logx.FieldQuery])
+	assert.Equal(t, "test-agent", entry[logx.FieldUA])
+	assert.NotEmpty(t, entry[logx.FieldRemoteIP])
+	assert.NotEmpty(t, entry[logx.FieldLatency])
+
+	// 請求頭打碼
+	headers := entry[logx.FieldHeaders].(map[string]any)
+	assert.Equal(t, "***", headers["Authorization"])
+	assert.Equal(t, "test-agent", headers["User-Agent"])
+}
+
+func TestAccessLogMiddleware_RemoteIP(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logx.Init(&logx.Options{Format: logx.FormatJson, Output: buf})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestContextMiddleware(&RequestContextOptions{TrustedProxies: []string{"10.0.0.0/8"}}), AccessLogMiddleware(nil))
+	r.GET("/ok", func(c *gin.Context) {
+		resp.OK(c, nil)
+	})
+
+	// 使用按可信代理解析出的地址，而不是 gin 預設信任所有代理得到的最左側地址
+	req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	req.Header.Set("X-Forwarded-For", "6.6.6.6, 1.2.3.4, 10.0.0.1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := accessEntries(t, buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "1.2.3.4", entries[0][logx.FieldRemoteIP])
+
+	// 沒有 RequestContextMiddleware 時退回 gin 的 ClientIP
+	r, buf = setupAccessLog(t, nil)
+	req = httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	entries = accessEntries(t, buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "10.0.0.2", entries[0][logx.FieldRemoteIP])
+}
+
+func TestAccessLogMiddleware_Level(t *testing.T) {
+	r, buf := setupAccessLog(t, nil)
+
+	// 業務錯誤碼
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	// panic 被 RecoveryMiddleware 轉為內部錯誤
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	// 404
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	entries := accessEntries(t, buf)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "warning", entries[0]["level"])
+	assert.Equal(t, float64(errorx.ErrNotFound.Code()), entries[0][logx.FieldCode])
+	assert.Equal(t, "error", entries[1]["level"])
+	assert.Equal(t, float64(errorx.ErrInternal.Code()), entries[1][logx.FieldCode])
+	assert.Equal(t, "warning", entries[2]["level"])
+	assert.Equal(t, float64(http.StatusNotFound), entries[2][logx.FieldStatus])
+}
+
+func TestAccessLogMiddleware_SkipAndSample(t *testing.T) {
+	r, buf := setupAccessLog(t, &AccessLogOptions{
+		SkipPaths:   []string{"/users/:id"},
+		SampleRates: map[string]float64{"/healthz": 0},
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+
+	entries := accessEntries(t, buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "/ok", entries[0][logx.FieldPath])
+}
+
+func TestAccessLogMiddleware_ErrorStack(t *testing.T) {
+	r, buf := setupAccessLog(t, nil)
+	r.GET("/wrapped", func(c *gin.Context) {
+		resp.Error(c, errorx.Wrap(io.ErrUnexpectedEOF, errorx.ErrNotFound))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/wrapped", nil))
+	// 響應中只有業務錯誤，不暴露內部錯誤
+	assert.JSONEq(t, `{"code":1004004,"success":false,"message":"resource not found"}`, w.Body.String())
+
+	entries := accessEntries(t, buf)
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0][logx.FieldError], "resource not found: unexpected EOF")
+	assert.Contains(t, entries[0][logx.FieldStack], "access_log_test.go")
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/logx"
+)
+
+// DebugLogMiddleware 允许单个请求通过 X-Debug-Log 请求头临时提高日志级别，
+// 值为 true 时输出 debug，也可以直接指定级别（如 trace）。
+// 对 logx.Init 配置的所有 Sink 生效；自定义 logx.Backend（如 slog.Handler）自身的级别过滤不受影响
+func DebugLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if level, ok := parseDebugLevel(c.GetHeader("X-Debug-Log")); ok {
+			logx.WithLevel(c, level)
+			c.Request = c.Request.WithContext(logx.WithLevel(c.Request.Context(), level))
+		}
+		c.Next()
+	}
+}
+
+func parseDebugLevel(s string) (logx.Level, bool) {
+	if s == "" {
+		return 0, false
+	}
+	if debug, err := strconv.ParseBool(s); err == nil {
+		return logx.LevelDebug, debug
+	}
+	level, err := logx.ParseLevel(s)
+	return level, err == nil
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/logx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugLogMiddleware(t *testing.T) {
+	logx.Init(&logx.Options{})
+	gin.SetMode(gin.TestMode)
+
+	for header, want := range map[string]logx.Level{
+		"":      logx.LevelInfo,
+		"false": logx.LevelInfo,
+		"bad":   logx.LevelInfo,
+		"true":  logx.LevelDebug,
+		"trace": logx.LevelTrace,
+	} {
+		r := gin.New()
+		r.Use(DebugLogMiddleware())
+		var ginLevel, reqLevel logx.Level
+		r.GET("/", func(c *gin.Context) {
+			ginLevel = enabledLevel(c)
+			reqLevel = enabledLevel(c.Request.Context())
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Debug-Log", header)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, want, ginLevel, header)
+		assert.Equal(t, want, reqLevel, header)
+	}
+}
+
+// enabledLevel 返回 ctx 下會輸出的最詳細級別
+func enabledLevel(ctx context.Context) logx.Level {
+	for level := logx.LevelTrace; level > logx.LevelPanic; level-- {
+		if logx.Enabled(ctx, level) {
+			return level
+		}
+	}
+	return logx.LevelPanic
+}
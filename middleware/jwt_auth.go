@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/auth"
+	"github.com/irvingos/go-tools/consts"
+	"github.com/irvingos/go-tools/errorx"
+	"github.com/irvingos/go-tools/jwtx"
+	"github.com/irvingos/go-tools/logx"
+	"github.com/irvingos/go-tools/resp"
+)
+
+type JWTAuthOptions struct {
+	jwtx.VerifyOptions
+
+	// 以下 claim 映射到 auth 中的用户信息，为空时使用默认值
+	UserIDClaim   string
+	TenantIDClaim string
+	UsernameClaim string
+	RolesClaim    string
+	ScopesClaim   string
+	// AllowMissingExp 为 true 时接受没有 exp 的 token，默认拒绝，即 VerifyOptions.RequireExp 始终生效
+	AllowMissingExp bool
+	// ParseID 把 UserIDClaim 和 TenantIDClaim 转换为 auth.ID 中的类型，返回 false 表示 claim 不存在或格式错误。
+	// 默认整数转为 int，其余非空字符串原样保留（如 UUID），需要 uuid.UUID 等类型时自定义
+	ParseID func(claims jwtx.Claims, name string) (any, bool)
+}
+
+func (o *JWTAuthOptions) normalize() {
+	if o.UserIDClaim == "" {
+		o.UserIDClaim = jwtx.ClaimSubject
+	}
+	if o.TenantIDClaim == "" {
+		o.TenantIDClaim = "tenant_id"
+	}
+	if o.UsernameClaim == "" {
+		o.UsernameClaim = "username"
+	}
+	if o.RolesClaim == "" {
+		o.RolesClaim = "roles"
+	}
+	if o.ScopesClaim == "" {
+		o.ScopesClaim = "scope"
+	}
+	if o.ParseID == nil {
+		o.ParseID = defaultParseID
+	}
+	o.RequireExp = !o.AllowMissingExp
+}
+
+func defaultParseID(claims jwtx.Claims, name string) (any, bool) {
+	if id, ok := claims.Int(name); ok {
+		return id, true
+	}
+	if id := claims.String(name); id != "" {
+		return id, true
+	}
+	return nil, false
+}
+
+// JWTAuthMiddleware 校验 Authorization 中的 Bearer token，并把 claims 写入 jwtx 的上下文、映射为 auth.Principal。
+// 缺少 token 时返回 errorx.ErrUnauthorized，token 无效（包括没有 exp）时返回 errorx.ErrInvalidToken。
+// o 为空或没有配置 KeySet 时所有 token 都无效
+func JWTAuthMiddleware(o *JWTAuthOptions) gin.HandlerFunc {
+	var opts JWTAuthOptions
+	if o != nil {
+		opts = *o
+	}
+	opts.normalize()
+
+	return func(c *gin.Context) {
+		header := c.GetHeader(consts.HTTP_HEADER_AUTHORIZATION)
+		token, ok := strings.CutPrefix(header, consts.HTTP_HEADER_AUTHORIZATION_PREFIX)
+		if !ok || token == "" {
+			resp.Error(c, errorx.ErrUnauthorized)
+			return
+		}
+
+		claims, err := jwtx.Verify(token, &opts.VerifyOptions)
+		if err != nil {
+			logx.WithContext(c).WithError(err).Warn("invalid token")
+			resp.Error(c, errorx.ErrInvalidToken)
+			return
+		}
+
+		userID, ok := opts.ParseID(claims, opts.UserIDClaim)
+		if !ok {
+			logx.WithContext(c).WithField(logx.FieldClaim, opts.UserIDClaim).Warn("invalid token")
+			resp.Error(c, errorx.ErrInvalidToken)
+			return
+		}
+		tenantID, _ := opts.ParseID(claims, opts.TenantIDClaim)
+		p := &auth.Principal{
+			UserID:   userID,
+			TenantID: tenantID,
+			Username: claims.String(opts.UsernameClaim),
+			Roles:    claims.Strings(opts.RolesClaim),
+			Scopes:   claims.Strings(opts.ScopesClaim),
+		}
+
+		setAuth(c, claims, p)
+		c.Request = c.Request.WithContext(setAuth(c.Request.Context(), claims, p))
+
+		c.Next()
+	}
+}
+
+func setAuth(ctx context.Context, claims jwtx.Claims, p *auth.Principal) context.Context {
+	return auth.WithPrincipal(jwtx.WithClaims(ctx, claims), p)
+}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/irvingos/go-tools/auth"
+	"github.com/irvingos/go-tools/consts"
+	"github.com/irvingos/go-tools/errorx"
+	"github.com/irvingos/go-tools/jwtx"
+	"github.com/irvingos/go-tools/logx"
+	"github.com/irvingos/go-tools/resp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testJWTKey = jwtx.Key{ID: "k1", Algorithm: jwtx.HS256, Key: []byte("secret")}
+
+func serveJWTAuth(t *testing.T, authorization string) (resp.Response, gin.H) {
+	logx.Init(&logx.Options{})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(JWTAuthMiddleware(&JWTAuthOptions{
+		VerifyOptions: jwtx.VerifyOptions{
+			KeySet:   jwtx.StaticKeySet{testJWTKey},
+			Issuer:   "test",
+			Audience: "api",
+		},
+		TenantIDClaim: "tid",
+	}))
+
+	var seen gin.H
+	r.GET("/", func(c *gin.Context) {
+		claims, _ := jwtx.ClaimsFrom(c)
+		seen = gin.H{
+			"user_id":      auth.UserIDFrom(c),
+			"tenant_id":    auth.TenantIDFrom(c),
+			"username":     auth.UsernameFrom(c),
+			"req_user_id":  auth.UserIDFrom(c.Request.Context()),
+			"claims_email": claims.String("email"),
+		}
+		resp.OK(c, nil)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		req.Header.Set(consts.HTTP_HEADER_AUTHORIZATION, authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var res resp.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	return res, seen
+}
+
+func TestJWTAuthMiddleware(t *testing.T) {
+	token, err := jwtx.Issue(testJWTKey, jwtx.Claims{
+		jwtx.ClaimIssuer:   "test",
+		jwtx.ClaimAudience: "api",
+		jwtx.ClaimSubject:  "42",
+		"tid":              7,
+		"username":         "alice",
+		"email":            "alice@example.com",
+	}, time.Minute)
+	require.NoError(t, err)
+
+	res, seen := serveJWTAuth(t, consts.HTTP_HEADER_AUTHORIZATION_PREFIX+token)
+	assert.True(t, res.Success)
+	assert.Equal(t, gin.H{
+		"user_id":      42,
+		"tenant_id":    7,
+		"username":     "alice",
+		"req_user_id":  42,
+		"claims_email": "alice@example.com",
+	}, seen)
+}
+
+func TestJWTAuthMiddleware_Unauthorized(t *testing.T) {
+	for _, authorization := range []string{"", "Basic abc", consts.HTTP_HEADER_AUTHORIZATION_PREFIX} {
+		res, seen := serveJWTAuth(t, authorization)
+		assert.Equal(t, errorx.ErrUnauthorized.Code(), res.Code)
+		assert.Nil(t, seen)
+	}
+}
+
+func TestJWTAuthMiddleware_InvalidToken(t *testing.T) {
+	valid := jwtx.Claims{jwtx.ClaimIssuer: "test", jwtx.ClaimAudience: "api", jwtx.ClaimSubject: "42"}
+
+	expired, _ := jwtx.Issue(testJWTKey, jwtx.Claims{
+		jwtx.ClaimIssuer:    "test",
+		jwtx.ClaimAudience:  "api",
+		jwtx.ClaimSubject:   "42",
+		jwtx.ClaimExpiresAt: time.Now().Add(-time.Minute).Unix(),
+	}, 0)
+	wrongKey, _ := jwtx.Issue(jwtx.Key{ID: "k1", Algorithm: jwtx.HS256, Key: []byte("other")}, valid, time.Minute)
+	wrongAudience, _ := jwtx.Issue(testJWTKey, jwtx.Claims{jwtx.ClaimIssuer: "test", jwtx.ClaimAudience: "web", jwtx.ClaimSubject: "42"}, time.Minute)
+	noSubject, _ := jwtx.Issue(testJWTKey, jwtx.Claims{jwtx.ClaimIssuer: "test", jwtx.ClaimAudience: "api"}, time.Minute)
+	// 默認拒絕沒有 exp 的 token
+	noExp, _ := jwtx.Issue(testJWTKey, valid, 0)
+
+	for _, token := range []string{"garbage", expired, wrongKey, wrongAudience, noSubject, noExp} {
+		res, seen := serveJWTAuth(t, consts.HTTP_HEADER_AUTHORIZATION_PREFIX+token)
+		assert.Equal(t, errorx.ErrInvalidToken.Code(), res.Code)
+		assert.Nil(t, seen)
+	}
+}
+
+func TestJWTAuthMiddleware_Options(t *testing.T) {
+	logx.Init(&logx.Options{})
+	noExp, err := jwtx.Issue(testJWTKey, jwtx.Claims{jwtx.ClaimSubject: "42"}, 0)
+	require.NoError(t, err)
+
+	serve := func(o *JWTAuthOptions) int {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.Use(JWTAuthMiddleware(o))
+		r.GET("/", func(c *gin.Context) {
+			resp.OK(c, nil)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(consts.HTTP_HEADER_AUTHORIZATION, consts.HTTP_HEADER_AUTHORIZATION_PREFIX+noExp)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var res resp.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return res.Code
+	}
+
+	// 沒有配置 KeySet 時不會 panic，所有 token 都無效
+	assert.Equal(t, errorx.ErrInvalidToken.Code(), serve(nil))
+	assert.Equal(t, errorx.ErrInvalidToken.Code(), serve(&JWTAuthOptions{}))
+
+	// AllowMissingExp 接受沒有 exp 的 token
+	keySet := jwtx.VerifyOptions{KeySet: jwtx.StaticKeySet{testJWTKey}}
+	assert.Equal(t, errorx.ErrInvalidToken.Code(), serve(&JWTAuthOptions{VerifyOptions: keySet}))
+	assert.Equal(t, 0, serve(&JWTAuthOptions{VerifyOptions: keySet, AllowMissingExp: true}))
+}
+
+func TestJWTAuthMiddleware_UUID(t *testing.T) {
+	logx.Init(&logx.Options{})
+	userID, tenantID := uuid.New(), uuid.New()
+	token, err := jwtx.Issue(testJWTKey, jwtx.Claims{
+		jwtx.ClaimSubject: userID.String(),
+		"tenant_id":       tenantID.String(),
+	}, time.Minute)
+	require.NoError(t, err)
+
+	serve := func(o *JWTAuthOptions) (any, any) {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.Use(JWTAuthMiddleware(o))
+		var gotUser, gotTenant any
+		r.GET("/", func(c *gin.Context) {
+			gotUser, _ = auth.UserIDValue(c)
+			gotTenant, _ = auth.TenantIDValue(c.Request.Context())
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(consts.HTTP_HEADER_AUTHORIZATION, consts.HTTP_HEADER_AUTHORIZATION_PREFIX+token)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		return gotUser, gotTenant
+	}
+
+	// 默認保留字符串形式的 UUID
+	gotUser, gotTenant := serve(&JWTAuthOptions{VerifyOptions: jwtx.VerifyOptions{KeySet: jwtx.StaticKeySet{testJWTKey}}})
+	assert.Equal(t, userID.String(), gotUser)
+	assert.Equal(t, tenantID.String(), gotTenant)
+
+	// 自定義 ParseID 轉換為 uuid.UUID
+	gotUser, gotTenant = serve(&JWTAuthOptions{
+		VerifyOptions: jwtx.VerifyOptions{KeySet: jwtx.StaticKeySet{testJWTKey}},
+		ParseID: func(claims jwtx.Claims, name string) (any, bool) {
+			id, err := uuid.Parse(claims.String(name))
+			return id, err == nil
+		},
+	})
+	assert.Equal(t, userID, gotUser)
+	assert.Equal(t, tenantID, gotTenant)
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/metrics"
+	"github.com/irvingos/go-tools/resp"
+)
+
+const (
+	// unmatchedRoute 是未匹配路由的请求使用的 route label，避免按请求路径产生大量 series
+	unmatchedRoute = "unmatched"
+	// otherMethod 是非标准 method 使用的 method label，method 由客户端决定，不能直接作为 label
+	otherMethod = "OTHER"
+)
+
+var knownMethods = map[string]struct{}{
+	http.MethodGet:     {},
+	http.MethodHead:    {},
+	http.MethodPost:    {},
+	http.MethodPut:     {},
+	http.MethodPatch:   {},
+	http.MethodDelete:  {},
+	http.MethodConnect: {},
+	http.MethodOptions: {},
+	http.MethodTrace:   {},
+}
+
+// MetricsMiddleware 按 method、路由模板、HTTP 状态码和业务码统计请求数和耗时，
+// 需要放在 RecoveryMiddleware 之前才能统计 panic 的请求
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+		elapsed := time.Since(start)
+
+		labels := []string{
+			methodLabel(ctx.Request.Method),
+			routeLabel(ctx),
+			strconv.Itoa(ctx.Writer.Status()),
+			strconv.Itoa(resp.CodeFrom(ctx)),
+		}
+		metrics.HTTPRequestsTotal.Inc(labels...)
+		metrics.HTTPRequestDuration.Observe(elapsed.Seconds(), labels...)
+	}
+}
+
+func routeLabel(ctx *gin.Context) string {
+	if route := ctx.FullPath(); route != "" {
+		return route
+	}
+	return unmatchedRoute
+}
+
+func methodLabel(method string) string {
+	if _, ok := knownMethods[method]; ok {
+		return method
+	}
+	return otherMethod
+}
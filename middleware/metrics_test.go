@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/errorx"
+	"github.com/irvingos/go-tools/logx"
+	"github.com/irvingos/go-tools/metrics"
+	"github.com/irvingos/go-tools/resp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	logx.Init(&logx.Options{Output: io.Discard})
+	t.Cleanup(func() { logx.Init(&logx.Options{}) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(MetricsMiddleware(), RecoveryMiddleware(nil))
+	r.GET("/users/:id", func(c *gin.Context) {
+		resp.Error(c, errorx.ErrNotFound)
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	r.GET("/metrics", metrics.Handler())
+
+	notFound := []string{http.MethodGet, "/users/:id", "200", "1004004"}
+	panicked := []string{http.MethodGet, "/panic", "200", "1005000"}
+	unmatched := []string{http.MethodGet, "unmatched", "404", "0"}
+	before := map[string]float64{
+		"notFound":  metrics.HTTPRequestsTotal.Value(notFound...),
+		"panicked":  metrics.HTTPRequestsTotal.Value(panicked...),
+		"unmatched": metrics.HTTPRequestsTotal.Value(unmatched...),
+		"panics":    metrics.HTTPPanicsRecovered.Value("/panic"),
+	}
+	durations := metrics.HTTPRequestDuration.Count(notFound...)
+
+	for _, path := range []string{"/users/1", "/users/2", "/panic", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// 按路由模板和業務碼統計，未匹配的路由歸到同一個 label
+	assert.Equal(t, before["notFound"]+2, metrics.HTTPRequestsTotal.Value(notFound...))
+	assert.Equal(t, durations+2, metrics.HTTPRequestDuration.Count(notFound...))
+	assert.Equal(t, before["panicked"]+1, metrics.HTTPRequestsTotal.Value(panicked...))
+	assert.Equal(t, before["unmatched"]+1, metrics.HTTPRequestsTotal.Value(unmatched...))
+	assert.Equal(t, before["panics"]+1, metrics.HTTPPanicsRecovered.Value("/panic"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",route="/users/:id",status="200",code="1004004"}`)
+	assert.Contains(t, w.Body.String(), "# TYPE http_request_duration_seconds histogram")
+}
+
+func TestMetricsMiddleware_OtherMethod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(MetricsMiddleware())
+	r.Handle("PROPFIND", "/dav", func(c *gin.Context) {})
+
+	// 非標準 method 歸到 OTHER，避免客戶端隨意產生 series
+	other := []string{"OTHER", "unmatched", "404", "0"}
+	before := metrics.HTTPRequestsTotal.Value(other...)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("X-RANDOM-1", "/missing", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("X-RANDOM-2", "/missing", nil))
+	assert.Equal(t, before+2, metrics.HTTPRequestsTotal.Value(other...))
+	assert.Zero(t, metrics.HTTPRequestsTotal.Value("X-RANDOM-1", "unmatched", "404", "0"))
+
+	dav := []string{"OTHER", "/dav", "200", "0"}
+	before = metrics.HTTPRequestsTotal.Value(dav...)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PROPFIND", "/dav", nil))
+	assert.Equal(t, before+1, metrics.HTTPRequestsTotal.Value(dav...))
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/auth"
+	"github.com/irvingos/go-tools/resp"
+)
+
+// RequirePermission 要求当前 principal 拥有全部 perms，需要放在认证中间件之后
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := auth.Authorize(c, perms...); err != nil {
+			resp.Error(c, err)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/auth"
+	"github.com/irvingos/go-tools/consts"
+	"github.com/irvingos/go-tools/errorx"
+	"github.com/irvingos/go-tools/jwtx"
+	"github.com/irvingos/go-tools/logx"
+	"github.com/irvingos/go-tools/resp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequirePermission(t *testing.T) {
+	logx.Init(&logx.Options{})
+	auth.SetPolicyProvider(auth.StaticPolicy{"editor": {"article:*"}})
+	t.Cleanup(func() { auth.SetPolicyProvider(nil) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(JWTAuthMiddleware(&JWTAuthOptions{
+		VerifyOptions: jwtx.VerifyOptions{KeySet: jwtx.StaticKeySet{testJWTKey}},
+	}))
+	r.POST("/articles", RequirePermission("article:write"), func(c *gin.Context) {
+		resp.OK(c, nil)
+	})
+	r.GET("/reports", RequirePermission("report:read"), func(c *gin.Context) {
+		resp.OK(c, nil)
+	})
+
+	serve := func(method, path string, claims jwtx.Claims) resp.Response {
+		token, err := jwtx.Issue(testJWTKey, claims, time.Minute)
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(consts.HTTP_HEADER_AUTHORIZATION, consts.HTTP_HEADER_AUTHORIZATION_PREFIX+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var res resp.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return res
+	}
+
+	editor := jwtx.Claims{jwtx.ClaimSubject: "1", "roles": []string{"editor"}}
+	assert.True(t, serve(http.MethodPost, "/articles", editor).Success)
+	assert.Equal(t, errorx.ErrForbidden.Code(), serve(http.MethodGet, "/reports", editor).Code)
+
+	// scope 中的權限直接生效
+	reporter := jwtx.Claims{jwtx.ClaimSubject: "2", "scope": "report:read profile"}
+	assert.True(t, serve(http.MethodGet, "/reports", reporter).Success)
+	assert.Equal(t, errorx.ErrForbidden.Code(), serve(http.MethodPost, "/articles", reporter).Code)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/irvingos/go-tools/errorx"
 	"github.com/irvingos/go-tools/logx"
+	"github.com/irvingos/go-tools/metrics"
 	"github.com/irvingos/go-tools/resp"
 )
 
@@ -27,6 +28,7 @@ func RecoveryMiddleware(hideHeaders []string) gin.HandlerFunc {
 		ctx.Writer = ecw
 		defer func() {
 			if r := recover(); r != nil {
+				metrics.HTTPPanicsRecovered.Inc(routeLabel(ctx))
 				if isBrokenPipe(r, ctx.Request) || isBrokenPipe(ecw.LastErr(), ctx.Request) {
 					logx.WithContext(ctx).
 						WithField(logx.FieldEvent, "panic_recovered_broken_pipe").
@@ -82,7 +84,7 @@ func topFrame() (fn, file string, line int) {
 func filterHeaders(h http.Header, hideHeaders []string) map[string]string {
 	hideHeaderSet := make(map[string]struct{})
 	for _, header := range hideHeaders {
-		hideHeaderSet[header] = struct{}{}
+		hideHeaderSet[strings.ToLower(header)] = struct{}{}
 	}
 
 	filtered := make(map[string]string, len(h))
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/consts"
+	"github.com/irvingos/go-tools/trace"
+)
+
+type RequestContextOptions struct {
+	// TrustedProxies 是可信代理的 IP 或 CIDR，只有直连地址可信时才解析 X-Forwarded-For / X-Real-IP
+	TrustedProxies []string
+}
+
+// RequestContextMiddleware 为每个请求确定请求 ID、trace id、客户端 IP 和 User-Agent，
+// 同时写入 gin.Context 和 Request.Context。
+// trace id 是 W3C 格式，依次取自 traceparent、W3C 格式的 x-request-id，都没有时生成新的，TracingMiddleware 沿用它作为 span 的 trace id；
+// 请求 ID 取自 x-request-id，没有或不合法（超过 128 个字符、包含非可打印 ASCII 字符）时与 trace id 相同，并在响应头中回写。日志同时输出两者
+func RequestContextMiddleware(o *RequestContextOptions) gin.HandlerFunc {
+	var trusted []netip.Prefix
+	if o != nil {
+		trusted = parseTrustedProxies(o.TrustedProxies)
+	}
+
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(consts.HTTP_HEADER_TRACE_ID)
+		if !validRequestID(requestID) {
+			requestID = ""
+		}
+		traceID, _, _, ok := trace.ParseTraceparent(c.GetHeader(consts.HTTP_HEADER_TRACEPARENT))
+		switch {
+		case ok:
+		case trace.IsTraceID(requestID):
+			traceID = requestID
+		default:
+			traceID = trace.NewTraceID()
+		}
+		if requestID == "" {
+			requestID = traceID
+		}
+		c.Header(consts.HTTP_HEADER_TRACE_ID, requestID)
+
+		clientIP := resolveClientIP(c, trusted)
+		userAgent := c.Request.UserAgent()
+
+		trace.WithRequestID(c, requestID)
+		trace.WithTraceID(c, traceID)
+		trace.WithClientIP(c, clientIP)
+		trace.WithUserAgent(c, userAgent)
+
+		var ctx context.Context = c.Request.Context()
+		ctx = trace.WithRequestID(ctx, requestID)
+		ctx = trace.WithTraceID(ctx, traceID)
+		ctx = trace.WithClientIP(ctx, clientIP)
+		ctx = trace.WithUserAgent(ctx, userAgent)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// maxRequestIDLen 限制上游传入的请求 ID 长度，避免超长的值写入日志和响应头
+const maxRequestIDLen = 128
+
+// validRequestID 判断请求 ID 是否只包含可打印的 ASCII 字符，防止换行等字符伪造日志
+func validRequestID(id string) bool {
+	if len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x20 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func parseTrustedProxies(proxies []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, p := range proxies {
+		if strings.Contains(p, "/") {
+			prefix, err := netip.ParsePrefix(p)
+			if err != nil {
+				panic(fmt.Sprintf("middleware: invalid trusted proxy %q: %v", p, err))
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(p)
+		if err != nil {
+			panic(fmt.Sprintf("middleware: invalid trusted proxy %q: %v", p, err))
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes
+}
+
+func isTrusted(trusted []netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveClientIP 从右向左遍历 X-Forwarded-For，跳过可信代理，第一个不可信的地址即客户端
+func resolveClientIP(c *gin.Context, trusted []netip.Prefix) string {
+	remoteIP, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		remoteIP = strings.TrimSpace(c.Request.RemoteAddr)
+	}
+	if !isTrusted(trusted, remoteIP) {
+		return remoteIP
+	}
+
+	if xff := c.GetHeader("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if _, err := netip.ParseAddr(hop); err != nil {
+				break
+			}
+			if i == 0 || !isTrusted(trusted, hop) {
+				return hop
+			}
+		}
+	}
+	if realIP := strings.TrimSpace(c.GetHeader("X-Real-IP")); realIP != "" {
+		if _, err := netip.ParseAddr(realIP); err == nil {
+			return realIP
+		}
+	}
+	return remoteIP
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/consts"
+	"github.com/irvingos/go-tools/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type requestValues struct {
+	requestID, traceID, clientIP, userAgent             string
+	reqRequestID, reqTraceID, reqClientIP, reqUserAgent string
+}
+
+func serveRequestContext(o *RequestContextOptions, req *http.Request) (*httptest.ResponseRecorder, requestValues) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestContextMiddleware(o))
+
+	var v requestValues
+	r.GET("/", func(c *gin.Context) {
+		v.requestID = trace.RequestIDFrom(c)
+		v.traceID = trace.TraceIDFrom(c)
+		v.clientIP = trace.ClientIPFrom(c)
+		v.userAgent = trace.UserAgentFrom(c)
+		v.reqRequestID = trace.RequestIDFrom(c.Request.Context())
+		v.reqTraceID = trace.TraceIDFrom(c.Request.Context())
+		v.reqClientIP = trace.ClientIPFrom(c.Request.Context())
+		v.reqUserAgent = trace.UserAgentFrom(c.Request.Context())
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, v
+}
+
+func TestRequestContextMiddleware_TraceID(t *testing.T) {
+	// 非 W3C 格式的 x-request-id 作為請求 ID 回寫，trace id 另外生成
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(consts.HTTP_HEADER_TRACE_ID, "req-1")
+	req.Header.Set("User-Agent", "test-agent")
+	w, v := serveRequestContext(nil, req)
+	assert.Equal(t, "req-1", v.requestID)
+	assert.Equal(t, "req-1", v.reqRequestID)
+	assert.True(t, trace.IsTraceID(v.traceID))
+	assert.Equal(t, v.traceID, v.reqTraceID)
+	assert.Equal(t, "test-agent", v.userAgent)
+	assert.Equal(t, "test-agent", v.reqUserAgent)
+	assert.Equal(t, "req-1", w.Header().Get(consts.HTTP_HEADER_TRACE_ID))
+
+	// W3C 格式的 x-request-id 同時作為 trace id
+	w3c := trace.NewTraceID()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(consts.HTTP_HEADER_TRACE_ID, w3c)
+	_, v = serveRequestContext(nil, req)
+	assert.Equal(t, w3c, v.requestID)
+	assert.Equal(t, w3c, v.traceID)
+
+	// trace id 優先使用 traceparent，請求 ID 仍然是 x-request-id
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(consts.HTTP_HEADER_TRACE_ID, "req-2")
+	req.Header.Set(consts.HTTP_HEADER_TRACEPARENT, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w, v = serveRequestContext(nil, req)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", v.traceID)
+	assert.Equal(t, "req-2", v.requestID)
+	assert.Equal(t, "req-2", w.Header().Get(consts.HTTP_HEADER_TRACE_ID))
+
+	// 沒有 x-request-id 時請求 ID 與 trace id 相同
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(consts.HTTP_HEADER_TRACEPARENT, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w, v = serveRequestContext(nil, req)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", v.traceID)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", v.requestID)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", w.Header().Get(consts.HTTP_HEADER_TRACE_ID))
+
+	// 不合法的 x-request-id 被丟棄，重新生成
+	for _, invalid := range []string{strings.Repeat("a", 129), "req\tid", "請求-1", "req\x7f"} {
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(consts.HTTP_HEADER_TRACE_ID, invalid)
+		w, v = serveRequestContext(nil, req)
+		assert.True(t, trace.IsTraceID(v.requestID), invalid)
+		assert.Equal(t, v.traceID, v.requestID)
+		assert.Equal(t, v.requestID, w.Header().Get(consts.HTTP_HEADER_TRACE_ID))
+	}
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(consts.HTTP_HEADER_TRACE_ID, strings.Repeat("a", 128))
+	_, v = serveRequestContext(nil, req)
+	assert.Equal(t, strings.Repeat("a", 128), v.requestID)
+
+	// 都沒有時生成
+	w, v = serveRequestContext(nil, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, trace.IsTraceID(v.traceID))
+	assert.Equal(t, v.traceID, v.requestID)
+	assert.Equal(t, v.traceID, w.Header().Get(consts.HTTP_HEADER_TRACE_ID))
+}
+
+func TestRequestContextMiddleware_ClientIP(t *testing.T) {
+	o := &RequestContextOptions{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}}
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		xRealIP    string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "1.2.3.4:1234", want: "1.2.3.4"},
+		{name: "untrusted proxy ignored", remoteAddr: "1.2.3.4:1234", xff: "5.6.7.8", want: "1.2.3.4"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:1234", xff: "5.6.7.8", want: "5.6.7.8"},
+		{name: "skip trusted hops", remoteAddr: "10.0.0.1:1234", xff: "9.9.9.9, 5.6.7.8, 192.168.1.1, 10.0.0.2", want: "5.6.7.8"},
+		{name: "all trusted", remoteAddr: "10.0.0.1:1234", xff: "10.0.0.3, 10.0.0.2", want: "10.0.0.3"},
+		{name: "x-real-ip", remoteAddr: "10.0.0.1:1234", xRealIP: "5.6.7.8", want: "5.6.7.8"},
+		{name: "invalid xff", remoteAddr: "10.0.0.1:1234", xff: "unknown", want: "10.0.0.1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.xff != "" {
+				req.Header.Set("X-Forwarded-For", tc.xff)
+			}
+			if tc.xRealIP != "" {
+				req.Header.Set("X-Real-IP", tc.xRealIP)
+			}
+			_, v := serveRequestContext(o, req)
+			assert.Equal(t, tc.want, v.clientIP)
+			assert.Equal(t, tc.want, v.reqClientIP)
+		})
+	}
+}
+
+func TestRequestContextMiddleware_InvalidTrustedProxy(t *testing.T) {
+	assert.Panics(t, func() {
+		RequestContextMiddleware(&RequestContextOptions{TrustedProxies: []string{"not-an-ip"}})
+	})
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/consts"
+	"github.com/irvingos/go-tools/trace"
+)
+
+// TracingMiddleware 为每个请求创建一个 server span，写入 gin.Context 和 Request.Context，
+// 后续通过 trace.Start 创建的 span 都是它的子 span。
+// 请求带有 W3C traceparent 时作为上游的子 span，否则沿用 RequestContextMiddleware 确定的 trace id，
+// 因此需要注册在 RequestContextMiddleware 之后
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var opts []trace.StartOption
+		if traceID, parentID, _, ok := trace.ParseTraceparent(c.GetHeader(consts.HTTP_HEADER_TRACEPARENT)); ok {
+			opts = append(opts, trace.WithRemoteParent(traceID, parentID))
+		}
+		clientIP := trace.ClientIPFrom(c)
+		if clientIP == "" {
+			clientIP = c.ClientIP()
+		}
+		opts = append(opts, trace.WithKind(trace.SpanKindServer), trace.WithAttributes(map[string]any{
+			"http.request.method": c.Request.Method,
+			"url.path":            c.Request.URL.Path,
+			"client.address":      clientIP,
+			"user_agent.original": c.Request.UserAgent(),
+		}))
+
+		ctx, span := trace.Start(c.Request.Context(), c.Request.Method, opts...)
+		defer span.End()
+		trace.WithSpan(c, span)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		// 路由匹配后才能拿到 FullPath，未匹配时保留 method 作为名称
+		if route := c.FullPath(); route != "" {
+			span.SetName(c.Request.Method + " " + route)
+			span.SetAttribute("http.route", route)
+		}
+		status := c.Writer.Status()
+		span.SetAttribute("http.response.status_code", status)
+		if status >= http.StatusInternalServerError {
+			if err := c.Errors.Last(); err != nil {
+				span.RecordError(err.Err)
+			} else {
+				span.SetStatus(trace.StatusError, fmt.Sprintf("%d %s", status, http.StatusText(status)))
+			}
+		}
+	}
+}
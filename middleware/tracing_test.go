@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/consts"
+	"github.com/irvingos/go-tools/logx"
+	"github.com/irvingos/go-tools/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	e := trace.NewInMemoryExporter()
+	trace.SetExporter(e)
+	t.Cleanup(func() { trace.SetExporter(nil) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestContextMiddleware(nil), TracingMiddleware())
+
+	var traceparent string
+	r.GET("/users/:id", func(c *gin.Context) {
+		// handler 中創建的 span 是 server span 的子 span
+		_, span := trace.Start(c, "load")
+		span.End()
+		traceparent = trace.Traceparent(c.Request.Context())
+	})
+	r.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(consts.HTTP_HEADER_TRACEPARENT, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("User-Agent", "test-agent")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := e.Spans()
+	require.Len(t, spans, 2)
+	child, server := spans[0], spans[1]
+	assert.Equal(t, "GET /users/:id", server.Name)
+	assert.Equal(t, trace.SpanKindServer, server.Kind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", server.ParentSpanID)
+	assert.Equal(t, "/users/:id", server.Attributes["http.route"])
+	assert.Equal(t, "/users/1", server.Attributes["url.path"])
+	assert.Equal(t, http.StatusOK, server.Attributes["http.response.status_code"])
+	assert.Equal(t, "test-agent", server.Attributes["user_agent.original"])
+	assert.Equal(t, trace.StatusUnset, server.Status)
+	assert.Equal(t, server.SpanID, child.ParentSpanID)
+	assert.Equal(t, trace.FormatTraceparent(server.TraceID, server.SpanID, true), traceparent)
+
+	// 沒有 traceparent 時沿用 x-request-id 對應的 trace id，5xx 標記為失敗
+	e.Reset()
+	req = httptest.NewRequest(http.MethodGet, "/fail", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	spans = e.Spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, w.Header().Get(consts.HTTP_HEADER_TRACE_ID), spans[0].TraceID)
+	assert.Empty(t, spans[0].ParentSpanID)
+	assert.Equal(t, trace.StatusError, spans[0].Status)
+}
+
+func TestTracingMiddleware_RequestID(t *testing.T) {
+	e := trace.NewInMemoryExporter()
+	trace.SetExporter(e)
+	t.Cleanup(func() { trace.SetExporter(nil) })
+	buf := &bytes.Buffer{}
+	logx.Init(&logx.Options{Format: logx.FormatJson, Output: buf})
+	t.Cleanup(func() { logx.Init(&logx.Options{}) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestContextMiddleware(nil), TracingMiddleware())
+	r.GET("/", func(c *gin.Context) {
+		logx.WithContext(c).Info("gin")
+		logx.WithContext(c.Request.Context()).Info("request")
+	})
+
+	// 非 W3C 格式的 x-request-id 原樣回寫，日誌中的 trace_id 與 span 一致
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(consts.HTTP_HEADER_TRACE_ID, "gw-req-12345")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "gw-req-12345", w.Header().Get(consts.HTTP_HEADER_TRACE_ID))
+
+	spans := e.Spans()
+	require.Len(t, spans, 1)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, "gw-req-12345", entry[logx.FieldRequestID])
+		assert.Equal(t, spans[0].TraceID, entry[logx.FieldTraceID])
+		assert.Equal(t, spans[0].SpanID, entry[logx.FieldSpanID])
+	}
+}
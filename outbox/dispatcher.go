@@ -0,0 +1,157 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/irvingos/go-tools/graceful"
+	"github.com/irvingos/go-tools/logx"
+	"github.com/irvingos/go-tools/timex"
+	"github.com/irvingos/go-tools/tx"
+	"gorm.io/gorm"
+)
+
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+type DispatcherOptions struct {
+	BatchSize    int
+	PollInterval time.Duration
+	// MaxAttempts 达到后消息标记为 StatusFailed
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// LeaseTimeout 是领取的消息的租约时长，超过该时间仍未标记结果的消息会被重新领取，应大于一批消息的投递耗时
+	LeaseTimeout time.Duration
+	// RuntimeManager 不为空时，每一批投递都登记为在途任务，Shutdown 会等待当前批次完成
+	RuntimeManager *graceful.RuntimeManager
+}
+
+func (o *DispatcherOptions) normalize() {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 10
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 10 * time.Minute
+	}
+	if o.LeaseTimeout <= 0 {
+		o.LeaseTimeout = time.Minute
+	}
+}
+
+func NewDispatcher(db *gorm.DB, publisher Publisher, o *DispatcherOptions) *Dispatcher {
+	var opts DispatcherOptions
+	if o != nil {
+		opts = *o
+	}
+	opts.normalize()
+
+	return &Dispatcher{
+		DispatcherOptions: opts,
+		repo:              NewRepo(db),
+		uow:               tx.NewGormUow(db),
+		publisher:         publisher,
+	}
+}
+
+type Dispatcher struct {
+	DispatcherOptions
+
+	repo      *Repo
+	uow       tx.Uow
+	publisher Publisher
+}
+
+// Run 循环投递消息，直到 ctx 结束或 RuntimeManager 开始关闭
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if d.shuttingDown() {
+			return
+		}
+
+		n, err := d.DispatchOnce(ctx)
+		if err != nil {
+			logx.WithContext(ctx).WithError(err).Error("outbox dispatch failed")
+		}
+		// 取满一批说明还有积压，不等待直接继续
+		if err == nil && n == d.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce 领取并投递一批消息，返回本批处理的消息数。
+// 领取在一个短事务中完成，投递在事务之外进行，不会在等待 Publisher 时持有行锁；
+// 每条消息投递后单独标记结果，标记失败的消息在租约到期后重新投递，即至少投递一次
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	if d.RuntimeManager != nil {
+		if !d.RuntimeManager.Begin() {
+			return 0, nil
+		}
+		defer d.RuntimeManager.End()
+	}
+
+	var msgs []*Message
+	err := d.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		msgs, err = d.repo.claim(ctx, d.BatchSize, time.Now().Add(d.LeaseTimeout))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	for _, msg := range msgs {
+		if err := d.dispatch(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(msgs), errors.Join(errs...)
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, msg *Message) error {
+	pubErr := d.publisher.Publish(ctx, msg)
+	if pubErr == nil {
+		return d.repo.markSent(ctx, msg)
+	}
+
+	attempts := msg.Attempts + 1
+	status := StatusPending
+	if attempts >= d.MaxAttempts {
+		status = StatusFailed
+	}
+
+	logx.WithContext(ctx).
+		WithField(logx.FieldOutboxID, msg.ID).
+		WithField(logx.FieldTopic, msg.Topic).
+		WithField(logx.FieldAttempt, attempts).
+		WithError(pubErr).
+		Warn("outbox publish failed")
+
+	return d.repo.markRetry(ctx, msg, status, time.Now().Add(timex.Backoff(attempts, d.BaseBackoff, d.MaxBackoff)), pubErr)
+}
+
+func (d *Dispatcher) shuttingDown() bool {
+	return d.RuntimeManager != nil && d.RuntimeManager.IsShuttingDown()
+}
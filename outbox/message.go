@@ -0,0 +1,32 @@
+package outbox
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusSent    Status = "sent"
+	// StatusFailed 超过最大重试次数，不再投递
+	StatusFailed Status = "failed"
+)
+
+type Message struct {
+	ID            int64          `gorm:"primaryKey"`
+	Topic         string         `gorm:"size:255;not null"`
+	Payload       datatypes.JSON `gorm:"not null"`
+	Status        Status         `gorm:"size:16;not null;index:idx_outbox_messages_dispatch,priority:1"`
+	Attempts      int            `gorm:"not null;default:0"`
+	NextAttemptAt time.Time      `gorm:"not null;index:idx_outbox_messages_dispatch,priority:2"`
+	LastError     string
+	CreatedAt     time.Time
+	SentAt        *time.Time
+}
+
+func (Message) TableName() string {
+	return "outbox_messages"
+}
@@ -0,0 +1,240 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/irvingos/go-tools/graceful"
+	"github.com/irvingos/go-tools/logx"
+	"github.com/irvingos/go-tools/tx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	logx.Init(&logx.Options{})
+}
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "outbox.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Message{}))
+	return db
+}
+
+type recordPublisher struct {
+	mu   sync.Mutex
+	err  error
+	msgs []*Message
+}
+
+func (p *recordPublisher) Publish(ctx context.Context, msg *Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.msgs = append(p.msgs, msg)
+	return nil
+}
+
+func (p *recordPublisher) published() []*Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.msgs
+}
+
+func TestRepo_Enqueue_JoinUow(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewRepo(db)
+	uow := tx.NewGormUow(db)
+	ctx := context.Background()
+
+	// 事務提交時消息一起寫入
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		return repo.Enqueue(ctx, "user.created", map[string]any{"id": 1})
+	})
+	assert.NoError(t, err)
+
+	// 事務回滾時消息一起回滾
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		assert.NoError(t, repo.Enqueue(ctx, "user.deleted", map[string]any{"id": 1}))
+		return errors.New("test error")
+	})
+	assert.Error(t, err)
+
+	var msgs []Message
+	require.NoError(t, db.Find(&msgs).Error)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "user.created", msgs[0].Topic)
+	assert.Equal(t, StatusPending, msgs[0].Status)
+	assert.JSONEq(t, `{"id":1}`, string(msgs[0].Payload))
+}
+
+func TestDispatcher_DispatchOnce(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewRepo(db)
+	ctx := context.Background()
+
+	for _, topic := range []string{"a", "b", "c"} {
+		require.NoError(t, repo.Enqueue(ctx, topic, topic))
+	}
+
+	pub := &recordPublisher{}
+	d := NewDispatcher(db, pub, &DispatcherOptions{BatchSize: 2})
+
+	n, err := d.DispatchOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = d.DispatchOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// 已投遞的消息不再重複投遞
+	n, err = d.DispatchOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	var topics []string
+	for _, msg := range pub.published() {
+		topics = append(topics, msg.Topic)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, topics)
+
+	var sent int64
+	db.Model(&Message{}).Where("status = ? AND sent_at IS NOT NULL", StatusSent).Count(&sent)
+	assert.Equal(t, int64(3), sent)
+}
+
+func TestDispatcher_RetryWithBackoff(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewRepo(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Enqueue(ctx, "a", "a"))
+
+	pub := &recordPublisher{err: errors.New("broker unavailable")}
+	d := NewDispatcher(db, pub, &DispatcherOptions{
+		MaxAttempts: 2,
+		BaseBackoff: time.Hour,
+		MaxBackoff:  2 * time.Hour,
+	})
+
+	n, err := d.DispatchOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	var msg Message
+	require.NoError(t, db.First(&msg).Error)
+	assert.Equal(t, StatusPending, msg.Status)
+	assert.Equal(t, 1, msg.Attempts)
+	assert.Equal(t, "broker unavailable", msg.LastError)
+	assert.True(t, msg.NextAttemptAt.After(time.Now().Add(20*time.Minute)))
+
+	// 未到重試時間不會被領取
+	n, err = d.DispatchOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// 到期後再次失敗，超過最大次數標記為失敗
+	require.NoError(t, db.Model(&msg).Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+	n, err = d.DispatchOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	require.NoError(t, db.First(&msg).Error)
+	assert.Equal(t, StatusFailed, msg.Status)
+	assert.Equal(t, 2, msg.Attempts)
+}
+
+type funcPublisher func(ctx context.Context, msg *Message) error
+
+func (f funcPublisher) Publish(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+func TestDispatcher_Lease(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewRepo(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Enqueue(ctx, "a", "a"))
+
+	// 投遞時不持有事務，租約期內其他 dispatcher 不會領取同一條消息
+	other := NewDispatcher(db, &recordPublisher{}, nil)
+	published := 0
+	d := NewDispatcher(db, funcPublisher(func(ctx context.Context, msg *Message) error {
+		published++
+		_, inTx := tx.GormTxFrom(ctx)
+		assert.False(t, inTx)
+		n, err := other.DispatchOnce(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+		return nil
+	}), &DispatcherOptions{LeaseTimeout: time.Hour})
+
+	n, err := d.DispatchOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, published)
+
+	var msg Message
+	require.NoError(t, db.First(&msg).Error)
+	assert.Equal(t, StatusSent, msg.Status)
+
+	// 領取後沒有標記結果（如進程崩潰），租約到期後重新投遞
+	require.NoError(t, repo.Enqueue(ctx, "b", "b"))
+	msgs, err := repo.claim(ctx, 10, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	n, err = other.DispatchOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	require.NoError(t, db.Model(msgs[0]).Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+	n, err = other.DispatchOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestDispatcher_RunStopsOnShutdown(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewRepo(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Enqueue(ctx, "a", "a"))
+
+	rm := &graceful.RuntimeManager{}
+	pub := &recordPublisher{}
+	d := NewDispatcher(db, pub, &DispatcherOptions{
+		PollInterval:   10 * time.Millisecond,
+		RuntimeManager: rm,
+	})
+
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return len(pub.published()) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	assert.NoError(t, rm.Shutdown(shutdownCtx))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run should return after shutdown")
+	}
+}
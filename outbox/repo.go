@@ -0,0 +1,79 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/irvingos/go-tools/gormx"
+	"github.com/irvingos/go-tools/tx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func NewRepo(db *gorm.DB) *Repo {
+	return &Repo{BaseRepo: tx.NewBaseRepo(db)}
+}
+
+type Repo struct {
+	tx.BaseRepo
+}
+
+// Enqueue 将 payload 序列化为 JSON 写入 outbox，在 Uow.Do 中调用时与业务数据处于同一事务
+func (r *Repo) Enqueue(ctx context.Context, topic string, payload any) error {
+	b, err := gormx.ToJSON(payload)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return r.DBFrom(ctx).Create(&Message{
+		Topic:         topic,
+		Payload:       b,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}).Error
+}
+
+// claim 锁定一批到期的待投递消息，并把 next_attempt_at 推迟到 leaseUntil 作为租约，应在一个短事务中调用。
+// 已被其他 dispatcher 锁定的行会被跳过；事务提交后租约期内的消息不会被再次领取，
+// 投递进程崩溃时租约到期后由其他 dispatcher 重新投递
+func (r *Repo) claim(ctx context.Context, limit int, leaseUntil time.Time) ([]*Message, error) {
+	var msgs []*Message
+	err := r.DBFrom(ctx).
+		Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+		Where("status = ? AND next_attempt_at <= ?", StatusPending, time.Now()).
+		Order("id").
+		Limit(limit).
+		Find(&msgs).Error
+	if err != nil || len(msgs) == 0 {
+		return msgs, err
+	}
+
+	ids := make([]int64, len(msgs))
+	for i, msg := range msgs {
+		ids[i] = msg.ID
+		msg.NextAttemptAt = leaseUntil
+	}
+	err = r.DBFrom(ctx).Model(&Message{}).Where("id IN ?", ids).Update("next_attempt_at", leaseUntil).Error
+	return msgs, err
+}
+
+// markSent 和 markRetry 各自是一条 UPDATE，不需要额外的事务
+func (r *Repo) markSent(ctx context.Context, msg *Message) error {
+	now := time.Now()
+	return r.DBFrom(ctx).Model(msg).Updates(map[string]any{
+		"status":     StatusSent,
+		"attempts":   msg.Attempts + 1,
+		"last_error": "",
+		"sent_at":    now,
+	}).Error
+}
+
+func (r *Repo) markRetry(ctx context.Context, msg *Message, status Status, nextAttemptAt time.Time, cause error) error {
+	return r.DBFrom(ctx).Model(msg).Updates(map[string]any{
+		"status":          status,
+		"attempts":        msg.Attempts + 1,
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      cause.Error(),
+	}).Error
+}
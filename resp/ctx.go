@@ -3,25 +3,16 @@ package resp
 import (
 	"context"
 
-	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/ctxkey"
 )
 
-type codeKey struct{}
+var codeKey = ctxkey.NewCarried[int]("resp.code")
 
 func WithCode(ctx context.Context, code int) context.Context {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		gCtx.Set(codeKey{}, code)
-		return gCtx
-	}
-	return context.WithValue(ctx, codeKey{}, code)
+	return codeKey.With(ctx, code)
 }
 
 func CodeFrom(ctx context.Context) int {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		return gCtx.GetInt(codeKey{})
-	}
-	if code, ok := ctx.Value(codeKey{}).(int); ok {
-		return code
-	}
-	return 0
+	code, _ := codeKey.From(ctx)
+	return code
 }
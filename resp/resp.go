@@ -59,8 +59,12 @@ func ErrorParam(g *gin.Context, err error) {
 	WithCode(g, errResolveParam.Code())
 }
 
+// Error 按错误链中的 errorx.Error 返回业务码和 message，其余错误作为内部错误。
+// err 同时记录到 gin.Context.Errors，访问日志可以输出完整的错误链和调用栈
 func Error(g *gin.Context, err error) {
-	if apiErr, ok := err.(errorx.Error); ok {
+	_ = g.Error(err)
+	var apiErr errorx.Error
+	if errors.As(err, &apiErr) {
 		g.AbortWithStatusJSON(http.StatusOK, Response{
 			Code:    apiErr.Code(),
 			Message: apiErr.Message(),
@@ -5,14 +5,21 @@ import (
 	"fmt"
 	"runtime/debug"
 	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/trace"
 )
 
+// Go 在新的 goroutine 中执行 fn 并恢复 panic。ctx 中有 span 时为 fn 创建子 span，panic 会记录到 span 上
 func Go(ctx context.Context, fn func(ctx context.Context)) {
 	go func() {
+		ctx, span := startSpan(ctx)
 		defer func() {
 			if err := recover(); err != nil {
 				fmt.Printf("[Go] panic: %v\n%s", err, debug.Stack())
+				recordPanic(span, err)
 			}
+			endSpan(span)
 		}()
 
 		fn(ctx)
@@ -23,12 +30,41 @@ func GoWithWaitGroup(ctx context.Context, wg *sync.WaitGroup, fn func(ctx contex
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		ctx, span := startSpan(ctx)
 		defer func() {
 			if r := recover(); r != nil {
 				fmt.Printf("[Go] panic: %v\n%s", r, debug.Stack())
+				recordPanic(span, r)
 			}
+			endSpan(span)
 		}()
 
 		fn(ctx)
 	}()
 }
+
+// startSpan 为 fn 创建子 span。*gin.Context 派生后会丢失 Keys 中的值，
+// 在其他 goroutine 中原地写入又会影响请求本身，因此 fn 收到原 ctx，span 只记录耗时和 panic
+func startSpan(ctx context.Context) (context.Context, *trace.Span) {
+	parent, ok := trace.SpanFrom(ctx)
+	if !ok {
+		return ctx, nil
+	}
+	if _, isGin := ctx.(*gin.Context); isGin {
+		_, span := trace.Start(context.Background(), "safego.Go", trace.WithRemoteParent(parent.TraceID, parent.SpanID))
+		return ctx, span
+	}
+	return trace.Start(ctx, "safego.Go")
+}
+
+func recordPanic(span *trace.Span, r any) {
+	if span != nil {
+		span.SetStatus(trace.StatusError, fmt.Sprintf("panic: %v", r))
+	}
+}
+
+func endSpan(span *trace.Span) {
+	if span != nil {
+		span.End()
+	}
+}
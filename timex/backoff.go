@@ -0,0 +1,17 @@
+package timex
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff 返回第 attempt 次失败后的等待时间：从 base 开始指数增长，不超过 max，一半固定一半随机
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	d = min(d, max)
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
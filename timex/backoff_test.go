@@ -0,0 +1,16 @@
+package timex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: 5 * time.Second} {
+		d := Backoff(attempt, time.Second, 5*time.Second)
+		assert.GreaterOrEqual(t, d, want/2, attempt)
+		assert.LessOrEqual(t, d, want, attempt)
+	}
+}
@@ -3,25 +3,16 @@ package trace
 import (
 	"context"
 
-	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/ctxkey"
 )
 
-type clientIPKey struct{}
+var clientIPKey = ctxkey.NewCarried[string]("trace.client_ip")
 
 func WithClientIP(ctx context.Context, clientIP string) context.Context {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		gCtx.Set(clientIPKey{}, clientIP)
-		return gCtx
-	}
-	return context.WithValue(ctx, clientIPKey{}, clientIP)
+	return clientIPKey.With(ctx, clientIP)
 }
 
 func ClientIPFrom(ctx context.Context) string {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		return gCtx.GetString(clientIPKey{})
-	}
-	if v, ok := ctx.Value(clientIPKey{}).(string); ok {
-		return v
-	}
-	return ""
+	clientIP, _ := clientIPKey.From(ctx)
+	return clientIP
 }
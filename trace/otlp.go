@@ -0,0 +1,304 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const instrumentationScope = "github.com/irvingos/go-tools/trace"
+
+type OTLPOptions struct {
+	// Endpoint 是 OTLP/HTTP 的 traces 地址，默认 http://localhost:4318/v1/traces
+	Endpoint    string
+	Headers     map[string]string
+	ServiceName string
+	// BatchSize 是每次发送的最大 span 数量，默认 512
+	BatchSize int
+	// MaxQueueSize 是等待发送的最大 span 数量，超出时丢弃新的 span，默认 2048
+	MaxQueueSize int
+	// FlushInterval 是定时发送的间隔，默认 5s
+	FlushInterval time.Duration
+	// Timeout 是每次发送的超时时间，默认 10s
+	Timeout time.Duration
+	Client  *http.Client
+	// OnError 处理发送失败，默认写到 stderr
+	OnError func(err error)
+}
+
+func (o *OTLPOptions) normalize() {
+	if o.Endpoint == "" {
+		o.Endpoint = "http://localhost:4318/v1/traces"
+	}
+	if o.ServiceName == "" {
+		o.ServiceName = "unknown_service"
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 512
+	}
+	if o.MaxQueueSize <= 0 {
+		o.MaxQueueSize = 2048
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = 5 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+	if o.OnError == nil {
+		o.OnError = func(err error) {
+			fmt.Fprintf(os.Stderr, "trace: otlp export: %v\n", err)
+		}
+	}
+}
+
+// OTLPExporter 以 OTLP/HTTP JSON 格式批量发送 span，可以直接对接 OpenTelemetry Collector
+type OTLPExporter struct {
+	OTLPOptions
+
+	mu       sync.Mutex
+	queue    []*Span
+	shutdown bool
+
+	flushCh chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewOTLPExporter 创建 exporter 并启动后台发送，o 为空时使用默认配置
+func NewOTLPExporter(o *OTLPOptions) *OTLPExporter {
+	var opts OTLPOptions
+	if o != nil {
+		opts = *o
+	}
+	opts.normalize()
+	e := &OTLPExporter{
+		OTLPOptions: opts,
+		flushCh:     make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// ExportSpans implements Exporter. span 先进入队列，由后台按批发送
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.shutdown {
+		return ErrExporterShutdown
+	}
+	if room := e.MaxQueueSize - len(e.queue); len(spans) > room {
+		spans = spans[:max(room, 0)]
+	}
+	e.queue = append(e.queue, spans...)
+	if len(e.queue) >= e.BatchSize {
+		select {
+		case e.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Shutdown implements Exporter. 停止后台发送并发送队列中剩余的 span
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	if e.shutdown {
+		e.mu.Unlock()
+		return nil
+	}
+	e.shutdown = true
+	e.mu.Unlock()
+
+	close(e.stop)
+	<-e.done
+	return e.flush(ctx)
+}
+
+func (e *OTLPExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.flushCh:
+		case <-e.stop:
+			return
+		}
+		if err := e.flush(context.Background()); err != nil {
+			e.OnError(err)
+		}
+	}
+}
+
+// flush 按 BatchSize 分批发送队列中的全部 span
+func (e *OTLPExporter) flush(ctx context.Context) error {
+	for {
+		e.mu.Lock()
+		n := min(len(e.queue), e.BatchSize)
+		batch := e.queue[:n:n]
+		e.queue = e.queue[n:]
+		e.mu.Unlock()
+
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := e.send(ctx, batch); err != nil {
+			return err
+		}
+	}
+}
+
+func (e *OTLPExporter) send(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := e.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("trace: otlp export: unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+// 以下类型对应 OTLP 的 JSON 编码，trace id 和 span id 使用十六进制字符串，64 位整数使用字符串
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func (e *OTLPExporter) encode(spans []*Span) otlpRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		out = append(out, otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentSpanID,
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.EndTime.UnixNano(), 10),
+			Attributes:        otlpAttributes(s.Attributes),
+			Status:            otlpStatus{Code: s.Status, Message: s.StatusMsg},
+		})
+		s.mu.Unlock()
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes(map[string]any{"service.name": e.ServiceName})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: instrumentationScope}, Spans: out}},
+	}}}
+}
+
+func otlpAttributes(attrs map[string]any) []otlpKeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, k := range keys {
+		kvs = append(kvs, otlpKeyValue{Key: k, Value: otlpValue(attrs[k])})
+	}
+	return kvs
+}
+
+func otlpValue(v any) otlpAnyValue {
+	switch x := v.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &x}
+	case bool:
+		return otlpAnyValue{BoolValue: &x}
+	case int:
+		s := strconv.FormatInt(int64(x), 10)
+		return otlpAnyValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(x, 10)
+		return otlpAnyValue{IntValue: &s}
+	case float64:
+		return otlpAnyValue{DoubleValue: &x}
+	default:
+		s := fmt.Sprint(v)
+		return otlpAnyValue{StringValue: &s}
+	}
+}
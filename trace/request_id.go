@@ -0,0 +1,20 @@
+package trace
+
+import (
+	"context"
+
+	"github.com/irvingos/go-tools/ctxkey"
+)
+
+var requestIDKey = ctxkey.NewCarried[string]("trace.request_id")
+
+// WithRequestID 保存请求 ID，即响应头中回写的 x-request-id，与 trace id 分开保存：
+// 上游传入的 x-request-id 不一定是 W3C 格式，不能作为 span 的 trace id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return requestIDKey.With(ctx, requestID)
+}
+
+func RequestIDFrom(ctx context.Context) string {
+	requestID, _ := requestIDKey.From(ctx)
+	return requestID
+}
@@ -0,0 +1,237 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/ctxkey"
+)
+
+type SpanKind int
+
+// 与 OTLP 的 SpanKind 取值一致
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+type StatusCode int
+
+// 与 OTLP 的 Status.Code 取值一致
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Span 是一次调用的耗时和上下文，End 之后交给 Exporter，不应再修改
+type Span struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	Kind         SpanKind
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   map[string]any
+	Status       StatusCode
+	StatusMsg    string
+
+	mu    sync.Mutex
+	ended bool
+	// restore 在 End 时把 *gin.Context 中的 span 恢复为父 span
+	restore func()
+}
+
+func (s *Span) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Name = name
+}
+
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]any)
+	}
+	s.Attributes[key] = value
+}
+
+func (s *Span) SetStatus(code StatusCode, msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Status = code
+	s.StatusMsg = msg
+}
+
+// RecordError 将 span 标记为失败，err 为 nil 时不做处理
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End 结束 span 并交给 Exporter，重复调用只有第一次生效
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	if s.restore != nil {
+		s.restore()
+	}
+	export(s)
+}
+
+type startOptions struct {
+	kind         SpanKind
+	attributes   map[string]any
+	traceID      string
+	parentSpanID string
+}
+
+type StartOption func(o *startOptions)
+
+func WithKind(kind SpanKind) StartOption {
+	return func(o *startOptions) {
+		o.kind = kind
+	}
+}
+
+// WithAttributes 设置 span 的属性，多次使用时合并
+func WithAttributes(attributes map[string]any) StartOption {
+	return func(o *startOptions) {
+		if o.attributes == nil {
+			o.attributes = make(map[string]any, len(attributes))
+		}
+		for k, v := range attributes {
+			o.attributes[k] = v
+		}
+	}
+}
+
+// WithRemoteParent 指定来自上游（如 traceparent 请求头）的父 span，优先于 ctx 中的 span
+func WithRemoteParent(traceID, parentSpanID string) StartOption {
+	return func(o *startOptions) {
+		o.traceID = traceID
+		o.parentSpanID = parentSpanID
+	}
+}
+
+var spanKey = ctxkey.NewCarried[*Span]("trace.span")
+
+// Start 创建 ctx 中当前 span 的子 span，没有时沿用 ctx 中的 trace id 创建根 span。
+// 调用方需要使用返回的 ctx 才能创建子 span。
+// *gin.Context 派生后读不到 Keys 中的事务、租户等值，因此原地写入并返回原 ctx，End 时恢复为父 span，
+// 不能在多个 goroutine 中对同一个 *gin.Context 调用 Start
+func Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	o := startOptions{kind: SpanKindInternal}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	span := &Span{
+		SpanID:    NewSpanID(),
+		Name:      name,
+		Kind:      o.kind,
+		StartTime: time.Now(),
+	}
+	for k, v := range o.attributes {
+		span.SetAttribute(k, v)
+	}
+
+	switch parent, ok := SpanFrom(ctx); {
+	case o.traceID != "":
+		span.TraceID, span.ParentSpanID = o.traceID, o.parentSpanID
+	case ok:
+		span.TraceID, span.ParentSpanID = parent.TraceID, parent.SpanID
+	default:
+		span.TraceID = TraceIDFrom(ctx)
+		if !IsTraceID(span.TraceID) {
+			span.TraceID = NewTraceID()
+		}
+	}
+
+	if gCtx, ok := ctx.(*gin.Context); ok {
+		parent, _ := spanKey.From(gCtx)
+		WithSpan(gCtx, span)
+		span.restore = func() {
+			// 当前 span 已被替换（如子 span 尚未结束）时不覆盖
+			if cur, _ := spanKey.From(gCtx); cur == span {
+				spanKey.With(gCtx, parent)
+			}
+		}
+		return gCtx, span
+	}
+
+	ctx = context.WithValue(ctx, spanKey, span)
+	if TraceIDFrom(ctx) != span.TraceID {
+		ctx = context.WithValue(ctx, traceKey, span.TraceID)
+	}
+	return ctx, span
+}
+
+func SpanFrom(ctx context.Context) (*Span, bool) {
+	span, ok := spanKey.From(ctx)
+	return span, ok && span != nil
+}
+
+// WithSpan 把 span 写入 ctx，*gin.Context 会被原地修改，用于中间件让后续的 handler 拿到 span
+func WithSpan(ctx context.Context, span *Span) context.Context {
+	ctx = spanKey.With(ctx, span)
+	return WithTraceID(ctx, span.TraceID)
+}
+
+// Exporter 接收已结束的 span，实现需要是并发安全的
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []*Span) error
+	Shutdown(ctx context.Context) error
+}
+
+var (
+	exporterMu sync.RWMutex
+	exporter   Exporter
+)
+
+// SetExporter 设置全局的 Exporter，为 nil 时 span 只用于传递 trace id，不会导出
+func SetExporter(e Exporter) {
+	exporterMu.Lock()
+	defer exporterMu.Unlock()
+	exporter = e
+}
+
+// Shutdown 关闭全局的 Exporter，导出尚未发送的 span
+func Shutdown(ctx context.Context) error {
+	exporterMu.Lock()
+	e := exporter
+	exporter = nil
+	exporterMu.Unlock()
+	if e == nil {
+		return nil
+	}
+	return e.Shutdown(ctx)
+}
+
+func export(span *Span) {
+	exporterMu.RLock()
+	e := exporter
+	exporterMu.RUnlock()
+	if e == nil {
+		return
+	}
+	// 导出失败不影响业务，由 Exporter 自行处理重试和日志
+	_ = e.ExportSpans(context.Background(), []*Span{span})
+}
+
+var ErrExporterShutdown = errors.New("trace: exporter is shut down")
@@ -0,0 +1,198 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/ctxkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupExporter(t *testing.T) *InMemoryExporter {
+	e := NewInMemoryExporter()
+	SetExporter(e)
+	t.Cleanup(func() { SetExporter(nil) })
+	return e
+}
+
+func TestStart(t *testing.T) {
+	e := setupExporter(t)
+
+	ctx, root := Start(context.Background(), "root", WithKind(SpanKindServer))
+	assert.True(t, IsTraceID(root.TraceID))
+	assert.Empty(t, root.ParentSpanID)
+	assert.Equal(t, root.TraceID, TraceIDFrom(ctx))
+
+	childCtx, child := Start(ctx, "child", WithAttributes(map[string]any{"a": 1}), WithAttributes(map[string]any{"b": 2}))
+	assert.Equal(t, root.TraceID, child.TraceID)
+	assert.Equal(t, root.SpanID, child.ParentSpanID)
+	assert.Equal(t, map[string]any{"a": 1, "b": 2}, child.Attributes)
+	assert.Equal(t, FormatTraceparent(child.TraceID, child.SpanID, true), Traceparent(childCtx))
+
+	// 重複 End 只導出一次
+	child.RecordError(errors.New("boom"))
+	child.End()
+	child.End()
+	root.End()
+
+	spans := e.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, StatusError, spans[0].Status)
+	assert.Equal(t, "boom", spans[0].StatusMsg)
+	assert.Equal(t, "root", spans[1].Name)
+	assert.False(t, spans[1].EndTime.Before(spans[1].StartTime))
+
+	e.Reset()
+	assert.Empty(t, e.Spans())
+}
+
+func TestStart_Parent(t *testing.T) {
+	// 沿用 ctx 中的 trace id
+	traceID := NewTraceID()
+	_, span := Start(WithTraceID(context.Background(), traceID), "root")
+	assert.Equal(t, traceID, span.TraceID)
+
+	// 非 W3C 格式的 trace id 不能用於 span
+	_, span = Start(WithTraceID(context.Background(), "req-1"), "root")
+	assert.True(t, IsTraceID(span.TraceID))
+
+	// 遠端父 span 優先於 ctx 中的 span
+	ctx, _ := Start(context.Background(), "local")
+	remoteTraceID, remoteSpanID := NewTraceID(), NewSpanID()
+	_, span = Start(ctx, "server", WithRemoteParent(remoteTraceID, remoteSpanID))
+	assert.Equal(t, remoteTraceID, span.TraceID)
+	assert.Equal(t, remoteSpanID, span.ParentSpanID)
+}
+
+func TestStart_GinContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+	key := ctxkey.New[string]("user")
+	key.With(c, "alice")
+	_, server := Start(context.Background(), "server")
+	WithSpan(c, server)
+
+	// *gin.Context 原地寫入，Keys 中的其他值仍然可以讀取
+	ctx, span := Start(c, "load")
+	assert.Same(t, c, ctx)
+	assert.Equal(t, server.SpanID, span.ParentSpanID)
+	assert.Equal(t, "alice", key.MustFrom(ctx))
+	current, _ := SpanFrom(c)
+	assert.Same(t, span, current)
+
+	// End 後恢復為父 span
+	_, child := Start(c, "child")
+	assert.Equal(t, span.SpanID, child.ParentSpanID)
+	child.End()
+	span.End()
+	current, _ = SpanFrom(c)
+	assert.Same(t, server, current)
+}
+
+func TestSpan_NoExporter(t *testing.T) {
+	SetExporter(nil)
+	_, span := Start(context.Background(), "noop")
+	assert.NotPanics(t, span.End)
+	assert.NoError(t, Shutdown(context.Background()))
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []otlpRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		var req otlpRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	e := NewOTLPExporter(&OTLPOptions{
+		Endpoint:      srv.URL,
+		Headers:       map[string]string{"X-Api-Key": "secret"},
+		ServiceName:   "svc",
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	})
+	SetExporter(e)
+	t.Cleanup(func() { SetExporter(nil) })
+
+	ctx, root := Start(context.Background(), "root", WithKind(SpanKindServer))
+	_, child := Start(ctx, "child", WithAttributes(map[string]any{"s": "v", "i": 1, "f": 1.5, "b": true}))
+	child.SetStatus(StatusOK, "")
+	child.End()
+	root.End()
+	_, last := Start(context.Background(), "last")
+	last.End()
+
+	// 達到 BatchSize 時由後台發送，其餘的在 Shutdown 時發送
+	require.NoError(t, Shutdown(context.Background()))
+	assert.ErrorIs(t, e.ExportSpans(context.Background(), []*Span{last}), ErrExporterShutdown)
+
+	mu.Lock()
+	defer mu.Unlock()
+	var spans []otlpSpan
+	for _, req := range requests {
+		require.Len(t, req.ResourceSpans, 1)
+		rs := req.ResourceSpans[0]
+		assert.Equal(t, "service.name", rs.Resource.Attributes[0].Key)
+		assert.Equal(t, "svc", *rs.Resource.Attributes[0].Value.StringValue)
+		spans = append(spans, rs.ScopeSpans[0].Spans...)
+	}
+	require.Len(t, spans, 3)
+
+	c := spans[0]
+	assert.Equal(t, "child", c.Name)
+	assert.Equal(t, child.TraceID, c.TraceID)
+	assert.Equal(t, root.SpanID, c.ParentSpanID)
+	assert.Equal(t, SpanKindInternal, c.Kind)
+	assert.Equal(t, StatusOK, c.Status.Code)
+	assert.NotEmpty(t, c.StartTimeUnixNano)
+	attrs := map[string]otlpAnyValue{}
+	for _, kv := range c.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "v", *attrs["s"].StringValue)
+	assert.Equal(t, "1", *attrs["i"].IntValue)
+	assert.Equal(t, 1.5, *attrs["f"].DoubleValue)
+	assert.True(t, *attrs["b"].BoolValue)
+
+	assert.Equal(t, "root", spans[1].Name)
+	assert.Equal(t, SpanKindServer, spans[1].Kind)
+	assert.Equal(t, "last", spans[2].Name)
+}
+
+func TestOTLPExporter_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	e := NewOTLPExporter(&OTLPOptions{Endpoint: srv.URL, FlushInterval: time.Hour})
+	_, span := Start(context.Background(), "root")
+	span.End()
+	require.NoError(t, e.ExportSpans(context.Background(), []*Span{span}))
+	assert.ErrorContains(t, e.Shutdown(context.Background()), "503")
+}
+
+func TestOTLPExporter_NilOptions(t *testing.T) {
+	e := NewOTLPExporter(nil)
+	assert.Equal(t, "http://localhost:4318/v1/traces", e.Endpoint)
+	assert.Equal(t, "unknown_service", e.ServiceName)
+	// 沒有待發送的 span 時關閉不會請求
+	assert.NoError(t, e.Shutdown(context.Background()))
+}
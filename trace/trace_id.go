@@ -3,25 +3,16 @@ package trace
 import (
 	"context"
 
-	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/ctxkey"
 )
 
-type traceKey struct{}
+var traceKey = ctxkey.NewCarried[string]("trace.trace_id")
 
 func WithTraceID(ctx context.Context, traceID string) context.Context {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		gCtx.Set(traceKey{}, traceID)
-		return gCtx
-	}
-	return context.WithValue(ctx, traceKey{}, traceID)
+	return traceKey.With(ctx, traceID)
 }
 
 func TraceIDFrom(ctx context.Context) string {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		return gCtx.GetString(traceKey{})
-	}
-	if v, ok := ctx.Value(traceKey{}).(string); ok {
-		return v
-	}
-	return ""
+	traceID, _ := traceKey.From(ctx)
+	return traceID
 }
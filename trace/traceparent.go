@@ -0,0 +1,82 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// NewTraceID 生成 W3C Trace Context 格式的 trace id（32 位十六进制）
+func NewTraceID() string {
+	return randomHex(16)
+}
+
+// NewSpanID 生成 W3C Trace Context 格式的 span id（16 位十六进制）
+func NewSpanID() string {
+	return randomHex(8)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ParseTraceparent 解析 W3C traceparent 请求头，格式为 version-traceid-parentid-flags
+func ParseTraceparent(s string) (traceID, parentID string, sampled bool, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", "", false, false
+	}
+	// version 00 只能有 4 段，更高版本允许在后面追加字段
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", "", false, false
+	}
+	traceID, parentID, flags := parts[1], parts[2], parts[3]
+	if !isHex(parts[0], 2) || !IsTraceID(traceID) || !isHex(parentID, 16) || parentID == strings.Repeat("0", 16) || !isHex(flags, 2) {
+		return "", "", false, false
+	}
+	b, _ := hex.DecodeString(flags)
+	return traceID, parentID, b[0]&0x01 == 0x01, true
+}
+
+// FormatTraceparent 生成 version 00 的 traceparent 请求头
+func FormatTraceparent(traceID, spanID string, sampled bool) string {
+	flags := "00"
+	if sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", traceID, spanID, flags)
+}
+
+// Traceparent 为下游调用生成 traceparent 请求头，ctx 中有 span 时以它作为下游的父 span，
+// ctx 中的 trace id 不是 W3C 格式时返回空字符串
+func Traceparent(ctx context.Context) string {
+	if span, ok := SpanFrom(ctx); ok {
+		return FormatTraceparent(span.TraceID, span.SpanID, true)
+	}
+	traceID := TraceIDFrom(ctx)
+	if !IsTraceID(traceID) {
+		return ""
+	}
+	return FormatTraceparent(traceID, NewSpanID(), true)
+}
+
+// IsTraceID 判断 s 是否为合法的 W3C trace id
+func IsTraceID(s string) bool {
+	return isHex(s, 32) && s != strings.Repeat("0", 32)
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package trace
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	traceID, parentID, sampled, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.Equal(t, "00f067aa0ba902b7", parentID)
+	assert.True(t, sampled)
+
+	_, _, sampled, ok = ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	assert.True(t, ok)
+	assert.False(t, sampled)
+
+	// 更高版本允許追加字段
+	_, _, _, ok = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.True(t, ok)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+	} {
+		_, _, _, ok := ParseTraceparent(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestTraceparent(t *testing.T) {
+	// 非 W3C 格式的 trace id 不生成 traceparent
+	assert.Empty(t, Traceparent(WithTraceID(context.Background(), "custom-id")))
+
+	traceID := NewTraceID()
+	assert.True(t, IsTraceID(traceID))
+
+	header := Traceparent(WithTraceID(context.Background(), traceID))
+	parsedTraceID, spanID, sampled, ok := ParseTraceparent(header)
+	assert.True(t, ok)
+	assert.Equal(t, traceID, parsedTraceID)
+	assert.Len(t, spanID, 16)
+	assert.True(t, sampled)
+	assert.True(t, strings.HasPrefix(header, "00-"))
+}
@@ -3,25 +3,16 @@ package trace
 import (
 	"context"
 
-	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/ctxkey"
 )
 
-type userAgentKey struct{}
+var userAgentKey = ctxkey.NewCarried[string]("trace.user_agent")
 
 func WithUserAgent(ctx context.Context, userAgent string) context.Context {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		gCtx.Set(userAgentKey{}, userAgent)
-		return gCtx
-	}
-	return context.WithValue(ctx, userAgentKey{}, userAgent)
+	return userAgentKey.With(ctx, userAgent)
 }
 
 func UserAgentFrom(ctx context.Context) string {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		return gCtx.GetString(userAgentKey{})
-	}
-	if v, ok := ctx.Value(userAgentKey{}).(string); ok {
-		return v
-	}
-	return ""
+	userAgent, _ := userAgentKey.From(ctx)
+	return userAgent
 }
@@ -3,20 +3,66 @@ package tx
 import (
 	"context"
 
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/gormx"
 	"gorm.io/gorm"
 )
 
-func NewBaseRepo(db *gorm.DB) BaseRepo {
-	return BaseRepo{db: db}
+type RepoOption func(b *BaseRepo)
+
+// WithName 将 BaseRepo 绑定到命名连接，DBFrom 读取 WithGormTxNamed 写入的同名事务
+func WithName(name string) RepoOption {
+	return func(b *BaseRepo) {
+		b.name = name
+	}
+}
+
+func NewBaseRepo(db *gorm.DB, opts ...RepoOption) BaseRepo {
+	b := BaseRepo{db: db}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	if b.resolver != nil && len(b.resolver.replicas) > 0 {
+		registerReplicaPlugin(db)
+	}
+	return b
 }
 
 type BaseRepo struct {
-	db *gorm.DB
+	db       *gorm.DB
+	name     string
+	resolver *resolver
 }
 
+// DBFrom 优先返回 ctx 中的事务；配置了从库时，不在事务中且没有 WithPrimary 标记的读操作会路由到从库。
+// 返回的 DB 绑定了 ctx，gorm 插件（如 gormx.TenantPlugin）可以从中读取请求信息，
+// 事务回调中写入的值（如 gormx.WithoutTenant）同样生效。
+// *gin.Context 无法派生，事务已经绑定在同一个 gin.Context 上，直接返回
 func (b BaseRepo) DBFrom(ctx context.Context) *gorm.DB {
-	if t, ok := GormTxFrom(ctx); ok {
-		return t
+	if t, ok := GormTxFromNamed(ctx, b.name); ok {
+		if _, isGin := ctx.(*gin.Context); isGin {
+			return t
+		}
+		return t.WithContext(ctx)
+	}
+	db := b.db.WithContext(ctx)
+	if isPrimary(ctx) {
+		return db
 	}
-	return b.db
+	if replica := b.resolver.pick(); replica != nil {
+		return db.Set(replicaSetting, replica).Session(&gorm.Session{})
+	}
+	return db
+}
+
+func (b *BaseRepo) resolverOrNew() *resolver {
+	if b.resolver == nil {
+		b.resolver = &resolver{}
+	}
+	return b.resolver
+}
+
+// UpdateVersioned 在当前事务中以乐观锁更新 model，详见 gormx.UpdateVersioned
+func (b BaseRepo) UpdateVersioned(ctx context.Context, model gormx.Versioned, fields ...string) error {
+	return gormx.UpdateVersioned(b.DBFrom(ctx), model, fields...)
 }
@@ -5,7 +5,11 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/errorx"
+	"github.com/irvingos/go-tools/gormx"
+	"github.com/irvingos/go-tools/page"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func TestBaseRepo_DBFrom_StandardContext_WithTx(t *testing.T) {
@@ -17,9 +21,10 @@ func TestBaseRepo_DBFrom_StandardContext_WithTx(t *testing.T) {
 	tx := db.Begin()
 	ctxWithTx := WithGormTx(ctx, tx)
 
-	// 測試 DBFrom 返回 tx
+	// 測試 DBFrom 返回綁定了 ctx 的 tx
 	retrievedDB := repo.DBFrom(ctxWithTx)
-	assert.Equal(t, tx, retrievedDB)
+	assert.Equal(t, tx.Statement.ConnPool, retrievedDB.Statement.ConnPool)
+	assert.Equal(t, ctxWithTx, retrievedDB.Statement.Context)
 
 	// 驗證可以使用 tx 執行操作
 	err := retrievedDB.Exec("INSERT INTO test_users (name) VALUES (?)", "test").Error
@@ -31,9 +36,10 @@ func TestBaseRepo_DBFrom_StandardContext_WithoutTx(t *testing.T) {
 	repo := NewBaseRepo(db)
 	ctx := context.Background()
 
-	// 測試 DBFrom 返回默認 db
+	// 測試 DBFrom 返回綁定了 ctx 的默認 db
 	retrievedDB := repo.DBFrom(ctx)
-	assert.Equal(t, db, retrievedDB)
+	assert.Equal(t, db.Statement.ConnPool, retrievedDB.Statement.ConnPool)
+	assert.Equal(t, ctx, retrievedDB.Statement.Context)
 
 	// 驗證可以使用 db 執行操作
 	err := retrievedDB.Exec("INSERT INTO test_users (name) VALUES (?)", "test").Error
@@ -65,9 +71,10 @@ func TestBaseRepo_DBFrom_GinContext_WithoutTx(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	c, _ := gin.CreateTestContext(nil)
 
-	// 測試 DBFrom 返回默認 db
+	// 測試 DBFrom 返回綁定了 ctx 的默認 db
 	retrievedDB := repo.DBFrom(c)
-	assert.Equal(t, db, retrievedDB)
+	assert.Equal(t, db.Statement.ConnPool, retrievedDB.Statement.ConnPool)
+	assert.Equal(t, c, retrievedDB.Statement.Context)
 
 	// 驗證可以使用 db 執行操作
 	err := retrievedDB.Exec("INSERT INTO test_users (name) VALUES (?)", "test_gin_default").Error
@@ -88,7 +95,7 @@ func TestBaseRepo_DBFrom_IntegrationWithUow(t *testing.T) {
 		// 驗證返回的是 tx
 		tx, ok := GormTxFrom(ctx)
 		assert.True(t, ok)
-		assert.Equal(t, tx, dbFromRepo)
+		assert.Equal(t, tx.Statement.ConnPool, dbFromRepo.Statement.ConnPool)
 
 		// 使用 repo 的 DB 執行操作
 		err := dbFromRepo.Exec("INSERT INTO test_users (name) VALUES (?)", "integration_test").Error
@@ -150,13 +157,13 @@ func TestBaseRepo_DBFrom_MultipleRepos(t *testing.T) {
 	retrievedDB1 := repo1.DBFrom(ctxWithTx)
 	txFromCtx, ok := GormTxFrom(ctxWithTx)
 	assert.True(t, ok)
-	assert.Equal(t, txFromCtx, retrievedDB1)
+	assert.Equal(t, txFromCtx.Statement.ConnPool, retrievedDB1.Statement.ConnPool)
 
 	// repo2 應該返回 db2（因為 tx 是 db1 的，repo2 使用 db2）
 	retrievedDB2 := repo2.DBFrom(ctxWithTx)
 
 	// 驗證 repo1 返回的是 tx（在事務中）
-	assert.Equal(t, txFromCtx, retrievedDB1)
+	assert.Equal(t, txFromCtx.Statement.ConnPool, retrievedDB1.Statement.ConnPool)
 
 	// 驗證 repo2 返回的不是 tx（因為 tx 是 db1 的，repo2 應該返回 db2）
 	// 通過驗證 repo2 返回的 DB 可以執行操作來確認它是有效的 DB
@@ -173,3 +180,206 @@ func TestBaseRepo_DBFrom_MultipleRepos(t *testing.T) {
 	// 主要驗證：repo1 在事務中返回 tx，repo2 返回自己的 db
 	assert.NotNil(t, retrievedDB2, "repo2 should return a valid DB")
 }
+
+func setupTestReplicas(t *testing.T) (primary, replica1, replica2 *gorm.DB) {
+	primary = setupTestFileDBWithTable(t)
+	replica1 = setupTestFileDBWithTable(t)
+	replica2 = setupTestFileDBWithTable(t)
+
+	// 每個庫寫入不同的數據，用於區分查詢落在哪個庫
+	assert.NoError(t, primary.Exec("INSERT INTO test_users (name) VALUES (?)", "primary").Error)
+	assert.NoError(t, replica1.Exec("INSERT INTO test_users (name) VALUES (?)", "replica1").Error)
+	assert.NoError(t, replica2.Exec("INSERT INTO test_users (name) VALUES (?)", "replica2").Error)
+	return
+}
+
+func readName(t *testing.T, db *gorm.DB) string {
+	var name string
+	assert.NoError(t, db.Table("test_users").Select("name").Order("id").Limit(1).Scan(&name).Error)
+	return name
+}
+
+func TestBaseRepo_DBFrom_Replicas_RoundRobin(t *testing.T) {
+	primary, replica1, replica2 := setupTestReplicas(t)
+	repo := NewBaseRepo(primary, WithReplicas(replica1, replica2))
+	ctx := context.Background()
+
+	// 讀操作輪詢從庫
+	assert.Equal(t, "replica1", readName(t, repo.DBFrom(ctx)))
+	assert.Equal(t, "replica2", readName(t, repo.DBFrom(ctx)))
+	assert.Equal(t, "replica1", readName(t, repo.DBFrom(ctx)))
+
+	// Raw SELECT 同樣走從庫
+	var count int64
+	assert.NoError(t, repo.DBFrom(ctx).Raw("SELECT COUNT(*) FROM test_users WHERE name = ?", "replica2").Scan(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// 寫操作走主庫
+	assert.NoError(t, repo.DBFrom(ctx).Exec("INSERT INTO test_users (name) VALUES (?)", "write").Error)
+	assert.NoError(t, repo.DBFrom(ctx).Table("test_users").Create(map[string]any{"name": "create"}).Error)
+	assert.Equal(t, int64(1), countUsers(t, primary, "write"))
+	assert.Equal(t, int64(1), countUsers(t, primary, "create"))
+	assert.Equal(t, int64(0), countUsers(t, replica1, "write"))
+	assert.Equal(t, int64(0), countUsers(t, replica2, "create"))
+}
+
+func TestBaseRepo_DBFrom_Replicas_Primary(t *testing.T) {
+	primary, replica1, replica2 := setupTestReplicas(t)
+	repo := NewBaseRepo(primary, WithReplicas(replica1, replica2))
+	uow := NewGormUow(primary)
+	ctx := context.Background()
+
+	// WithPrimary 強制讀主庫
+	assert.Equal(t, "primary", readName(t, repo.DBFrom(WithPrimary(ctx))))
+
+	// 事務中讀主庫
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		assert.Equal(t, "primary", readName(t, repo.DBFrom(ctx)))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// gin.Context 上的 WithPrimary
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+	assert.Equal(t, "replica1", readName(t, repo.DBFrom(c)))
+	WithPrimary(c)
+	assert.Equal(t, "primary", readName(t, repo.DBFrom(c)))
+}
+
+func TestBaseRepo_DBFrom_Replicas_Policy(t *testing.T) {
+	primary, replica1, replica2 := setupTestReplicas(t)
+	ctx := context.Background()
+
+	repo := NewBaseRepo(primary, WithReplicas(replica1, replica2), WithReplicaPolicy(ReplicaPrimaryOnly))
+	assert.Equal(t, "primary", readName(t, repo.DBFrom(ctx)))
+
+	repo = NewBaseRepo(primary, WithReplicas(replica1, replica2), WithReplicaPolicy(ReplicaRandom))
+	for range 10 {
+		assert.Contains(t, []string{"replica1", "replica2"}, readName(t, repo.DBFrom(ctx)))
+	}
+
+	// 沒有配置從庫的 BaseRepo 不受影響
+	repo = NewBaseRepo(primary)
+	assert.Equal(t, "primary", readName(t, repo.DBFrom(ctx)))
+}
+
+func TestRepo_Replicas(t *testing.T) {
+	primary, replica1, _ := setupTestReplicas(t)
+	repo := NewRepo[testUser](primary, WithReplicas(replica1))
+	ctx := context.Background()
+
+	users, err := repo.FindBy(ctx, "1 = 1")
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "replica1", users[0].Name)
+
+	p, err := repo.List(ctx, page.Spec{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), p.Total)
+	assert.Equal(t, "replica1", p.Data[0].Name)
+
+	assert.NoError(t, repo.Create(ctx, &testUser{Name: "created"}))
+	assert.Equal(t, int64(1), countUsers(t, primary, "created"))
+}
+
+type testUser struct {
+	ID   int64
+	Name string
+}
+
+func (testUser) TableName() string {
+	return "test_users"
+}
+
+type testDocument struct {
+	ID    int64
+	Title string
+	Body  string
+	gormx.Version
+}
+
+func TestBaseRepo_UpdateVersioned(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&testDocument{}))
+	repo := NewRepo[testDocument](db)
+	ctx := context.Background()
+
+	doc := &testDocument{Title: "v0", Body: "body"}
+	assert.NoError(t, repo.Create(ctx, doc))
+	assert.Equal(t, int64(0), doc.Version.Version)
+
+	// 兩個請求讀到同一版本
+	first, err := repo.FindByID(ctx, doc.ID)
+	assert.NoError(t, err)
+	second, err := repo.FindByID(ctx, doc.ID)
+	assert.NoError(t, err)
+
+	first.Title = "first"
+	assert.NoError(t, repo.UpdateVersioned(ctx, first))
+	assert.Equal(t, int64(1), first.Version.Version)
+
+	// 後提交的請求版本已過期
+	second.Title = "second"
+	err = repo.UpdateVersioned(ctx, second)
+	assert.Equal(t, errorx.ErrConflict, err)
+	assert.Equal(t, int64(0), second.Version.Version)
+
+	found, _ := repo.FindByID(ctx, doc.ID)
+	assert.Equal(t, "first", found.Title)
+	assert.Equal(t, int64(1), found.Version.Version)
+
+	// 指定字段更新
+	found.Title = "ignored"
+	found.Body = ""
+	assert.NoError(t, repo.UpdateVersioned(ctx, found, "body"))
+	found, _ = repo.FindByID(ctx, doc.ID)
+	assert.Equal(t, "first", found.Title)
+	assert.Equal(t, "", found.Body)
+	assert.Equal(t, int64(2), found.Version.Version)
+}
+
+func TestBaseRepo_UpdateVersioned_ZeroPrimaryKey(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&testDocument{}))
+	repo := NewRepo[testDocument](db)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.Create(ctx, &testDocument{Title: "a"}))
+	assert.NoError(t, repo.Create(ctx, &testDocument{Title: "b"}))
+
+	// 主鍵為零值時不能只靠 version 條件更新，否則會覆蓋所有同版本的行
+	err := repo.UpdateVersioned(ctx, &testDocument{Title: "clobbered"}, "title")
+	assert.ErrorIs(t, err, gorm.ErrPrimaryKeyRequired)
+
+	var count int64
+	assert.NoError(t, db.Model(&testDocument{}).Where("title = ?", "clobbered").Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestBaseRepo_UpdateVersioned_InUow(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&testDocument{}))
+	repo := NewRepo[testDocument](db)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	doc := &testDocument{Title: "v0"}
+	assert.NoError(t, repo.Create(ctx, doc))
+
+	// 衝突錯誤導致整個事務回滾
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		stale := *doc
+		doc.Title = "in_tx"
+		if err := repo.UpdateVersioned(ctx, doc); err != nil {
+			return err
+		}
+		stale.Title = "stale"
+		return repo.UpdateVersioned(ctx, &stale)
+	})
+	assert.Equal(t, errorx.ErrConflict, err)
+
+	found, _ := repo.FindByID(ctx, doc.ID)
+	assert.Equal(t, "v0", found.Title)
+	assert.Equal(t, int64(0), found.Version.Version)
+}
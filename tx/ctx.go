@@ -3,30 +3,70 @@ package tx
 import (
 	"context"
 
-	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/ctxkey"
 	"gorm.io/gorm"
 )
 
 type key struct{}
 
-func WithGormTx(ctx context.Context, tx *gorm.DB) context.Context {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		gCtx.Set(key{}, tx)
-		return gCtx
+// namedKey 用于多数据库场景下按连接名存放事务，默认连接（空名）仍使用 key{}
+type namedKey struct {
+	name string
+}
+
+func txKey(name string) any {
+	if name == "" {
+		return key{}
 	}
-	return context.WithValue(ctx, key{}, tx)
+	return namedKey{name: name}
+}
+
+func WithGormTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return WithGormTxNamed(ctx, "", tx)
 }
 
 func GormTxFrom(ctx context.Context) (*gorm.DB, bool) {
-	if gCtx, ok := ctx.(*gin.Context); ok {
-		v, exists := gCtx.Get(key{})
-		if !exists {
-			return nil, false
-		}
-		tx, ok := v.(*gorm.DB)
-		return tx, ok
+	return GormTxFromNamed(ctx, "")
+}
+
+func WithGormTxNamed(ctx context.Context, name string, tx *gorm.DB) context.Context {
+	return ctxkey.WithValue(ctx, txKey(name), tx)
+}
+
+func GormTxFromNamed(ctx context.Context, name string) (*gorm.DB, bool) {
+	tx, ok := ctxkey.Value[*gorm.DB](ctx, txKey(name))
+	return tx, ok && tx != nil
+}
+
+// state 是 Uow 为每个事务（或 SAVEPOINT）维护的附加信息
+type state struct {
+	options Options
+	hooks   *hooks
+	// unmanaged 为 true 表示外层事务不是由 Uow 开启的（SAVEPOINT 建在 WithGormTx 绑定的事务上），无法注册事务回调
+	unmanaged bool
+}
+
+type stateKey struct{}
+
+func withState(ctx context.Context, st *state) context.Context {
+	return ctxkey.WithValue(ctx, stateKey{}, st)
+}
+
+func stateFrom(ctx context.Context) (*state, bool) {
+	st, ok := ctxkey.Value[*state](ctx, stateKey{})
+	return st, ok && st != nil
+}
+
+// OptionsFrom 返回当前事务开启时使用的 Options
+func OptionsFrom(ctx context.Context) (Options, bool) {
+	st, ok := stateFrom(ctx)
+	if !ok {
+		return Options{}, false
 	}
-	v := ctx.Value(key{})
-	tx, ok := v.(*gorm.DB)
-	return tx, ok
+	return st.options, true
+}
+
+func IsReadOnly(ctx context.Context) bool {
+	o, ok := OptionsFrom(ctx)
+	return ok && o.ReadOnly
 }
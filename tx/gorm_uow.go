@@ -2,7 +2,10 @@ package tx
 
 import (
 	"context"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/ctxkey"
 	"gorm.io/gorm"
 )
 
@@ -17,8 +20,134 @@ type gormUow struct {
 }
 
 // Do implements Uow.
-func (u *gormUow) Do(ctx context.Context, fn func(ctx context.Context) error) error {
-	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		return fn(WithGormTx(ctx, tx))
+func (u *gormUow) Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	o := newOptions(opts)
+	current, inTx := GormTxFrom(ctx)
+
+	switch o.Propagation {
+	case PropagationRequired:
+		if inTx {
+			if err := checkJoin(ctx, o); err != nil {
+				return err
+			}
+			return fn(ctx)
+		}
+		return u.begin(ctx, o, fn)
+	case PropagationRequiresNew:
+		return u.begin(ctx, o, fn)
+	case PropagationSupports:
+		if inTx {
+			if err := checkJoin(ctx, o); err != nil {
+				return err
+			}
+		}
+		return fn(ctx)
+	case PropagationNever:
+		if inTx {
+			return ErrTxExists
+		}
+		return fn(ctx)
+	default:
+		if inTx {
+			if err := checkJoin(ctx, o); err != nil {
+				return err
+			}
+			return u.savepoint(ctx, current, o, fn)
+		}
+		return u.begin(ctx, o, fn)
+	}
+}
+
+func (u *gormUow) begin(ctx context.Context, o Options, fn func(ctx context.Context) error) error {
+	dbCtx, cancel := withTimeout(ctx, o.Timeout)
+	defer cancel()
+
+	st := &state{options: o, hooks: &hooks{}}
+	defer func() {
+		if r := recover(); r != nil {
+			st.hooks.runAfterRollback(ctx)
+			panic(r)
+		}
+	}()
+
+	err := u.db.WithContext(dbCtx).Transaction(func(tx *gorm.DB) error {
+		return run(ctx, dbCtx, tx, st, fn)
+	}, o.txOptions())
+	if err != nil {
+		st.hooks.runAfterRollback(ctx)
+		return err
+	}
+	st.hooks.runAfterCommit(ctx)
+	return nil
+}
+
+// savepoint 在已有事务上调用 Transaction，gorm 会创建 SAVEPOINT。
+// SAVEPOINT 回滚时立即执行其中注册的 AfterRollback，释放时把回调交给外层事务
+func (u *gormUow) savepoint(ctx context.Context, current *gorm.DB, o Options, fn func(ctx context.Context) error) error {
+	dbCtx, cancel := withTimeout(ctx, o.Timeout)
+	defer cancel()
+
+	parent, hasParent := stateFrom(ctx)
+	st := &state{hooks: &hooks{}, unmanaged: !hasParent}
+	if hasParent {
+		st.options = parent.options
+		st.unmanaged = parent.unmanaged
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			st.hooks.runAfterRollback(ctx)
+			panic(r)
+		}
+	}()
+
+	err := current.WithContext(dbCtx).Transaction(func(tx *gorm.DB) error {
+		return run(ctx, dbCtx, tx, st, fn)
 	})
+	if err != nil {
+		st.hooks.runAfterRollback(ctx)
+		return err
+	}
+	// 外层事务不是由 Uow 开启时无法注册回调，st.hooks 总是空的
+	if hasParent {
+		st.hooks.mergeInto(parent.hooks)
+	}
+	return nil
+}
+
+// withTimeout 为 tx 的 Statement.Context 加上 deadline。
+// 从 *gin.Context 派生的 ctx 读取不到 gin.Context 的 Keys，因此改为从 Request.Context() 派生，
+// 并复制 gin.Context 中通过 ctxkey.Register 声明的用户、租户等值，插件读到的值与不设置超时时一致
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	if gCtx, ok := ctx.(*gin.Context); ok {
+		base := context.Background()
+		if gCtx.Request != nil {
+			base = gCtx.Request.Context()
+		}
+		ctx = ctxkey.Carry(base, gCtx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// run 将 tx 和事务状态写入 ctx 后执行 fn。
+// gin.Context 无法派生，回调仍拿到原来的 gin.Context，超时只通过 tx 的 Statement.Context 生效；
+// 并且 gin.Context 是原地修改的，结束后需要恢复外层的值，避免外层继续拿到已经提交或回滚的 tx
+func run(ctx, dbCtx context.Context, tx *gorm.DB, st *state, fn func(ctx context.Context) error) error {
+	gCtx, ok := ctx.(*gin.Context)
+	if !ok {
+		return fn(withState(WithGormTx(dbCtx, tx), st))
+	}
+
+	prevTx, _ := gCtx.Get(key{})
+	prevState, _ := gCtx.Get(stateKey{})
+	defer func() {
+		gCtx.Set(key{}, prevTx)
+		gCtx.Set(stateKey{}, prevState)
+	}()
+
+	WithGormTx(gCtx, tx)
+	withState(gCtx, st)
+	return fn(gCtx)
 }
@@ -2,11 +2,14 @@ package tx
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func TestGormUow_Do_StandardContext(t *testing.T) {
@@ -153,3 +156,288 @@ func TestGormUow_Do_ContextPropagation(t *testing.T) {
 	})
 	assert.NoError(t, err)
 }
+
+func countUsers(t *testing.T, db *gorm.DB, name string) int64 {
+	var count int64
+	err := db.Raw("SELECT COUNT(*) FROM test_users WHERE name = ?", name).Scan(&count).Error
+	assert.NoError(t, err)
+	return count
+}
+
+func TestGormUow_Do_NestedRollbackToSavepoint(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	innerErr := errors.New("inner error")
+
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		tx, _ := GormTxFrom(ctx)
+		assert.NoError(t, tx.Exec("INSERT INTO test_users (name) VALUES (?)", "outer").Error)
+
+		// 內層失敗只回滾到保存點
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			tx, _ := GormTxFrom(ctx)
+			assert.NoError(t, tx.Exec("INSERT INTO test_users (name) VALUES (?)", "inner").Error)
+			return innerErr
+		})
+		assert.ErrorIs(t, err, innerErr)
+
+		// 外層事務仍然可用
+		tx, _ = GormTxFrom(ctx)
+		return tx.Exec("INSERT INTO test_users (name) VALUES (?)", "outer_after").Error
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(1), countUsers(t, db, "outer"))
+	assert.Equal(t, int64(0), countUsers(t, db, "inner"))
+	assert.Equal(t, int64(1), countUsers(t, db, "outer_after"))
+}
+
+func TestGormUow_Do_NestedRollbackToSavepoint_GinContext(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+
+	err := uow.Do(c, func(ctx context.Context) error {
+		outerTx, _ := GormTxFrom(ctx)
+		assert.NoError(t, outerTx.Exec("INSERT INTO test_users (name) VALUES (?)", "outer").Error)
+
+		_ = uow.Do(ctx, func(ctx context.Context) error {
+			tx, _ := GormTxFrom(ctx)
+			assert.NoError(t, tx.Exec("INSERT INTO test_users (name) VALUES (?)", "inner").Error)
+			return errors.New("inner error")
+		})
+
+		// 內層結束後 gin.Context 中恢復為外層的 tx
+		tx, ok := GormTxFrom(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, outerTx, tx)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// 事務結束後 gin.Context 中不再保留 tx
+	_, ok := GormTxFrom(c)
+	assert.False(t, ok)
+
+	assert.Equal(t, int64(1), countUsers(t, db, "outer"))
+	assert.Equal(t, int64(0), countUsers(t, db, "inner"))
+}
+
+func TestGormUow_Do_PropagationRequired(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	innerErr := errors.New("inner error")
+
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		outerTx, _ := GormTxFrom(ctx)
+		assert.NoError(t, outerTx.Exec("INSERT INTO test_users (name) VALUES (?)", "outer").Error)
+
+		// 加入外層事務，錯誤返回給外層後整個事務回滾
+		return uow.Do(ctx, func(ctx context.Context) error {
+			tx, _ := GormTxFrom(ctx)
+			assert.Equal(t, outerTx, tx)
+			assert.NoError(t, tx.Exec("INSERT INTO test_users (name) VALUES (?)", "inner").Error)
+			return innerErr
+		}, WithPropagation(PropagationRequired))
+	})
+	assert.ErrorIs(t, err, innerErr)
+
+	assert.Equal(t, int64(0), countUsers(t, db, "outer"))
+	assert.Equal(t, int64(0), countUsers(t, db, "inner"))
+}
+
+func TestGormUow_Do_PropagationRequiresNew(t *testing.T) {
+	db := setupTestFileDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	outerErr := errors.New("outer error")
+
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		outerTx, _ := GormTxFrom(ctx)
+
+		// 獨立事務先提交，不受外層回滾影響
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			tx, _ := GormTxFrom(ctx)
+			assert.NotEqual(t, outerTx, tx)
+			return tx.Exec("INSERT INTO test_users (name) VALUES (?)", "inner").Error
+		}, WithPropagation(PropagationRequiresNew))
+		assert.NoError(t, err)
+
+		return outerErr
+	})
+	assert.ErrorIs(t, err, outerErr)
+
+	assert.Equal(t, int64(1), countUsers(t, db, "inner"))
+}
+
+func TestGormUow_Do_PropagationSupports(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	// 沒有事務時以非事務方式執行
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		_, ok := GormTxFrom(ctx)
+		assert.False(t, ok)
+		return nil
+	}, WithPropagation(PropagationSupports))
+	assert.NoError(t, err)
+
+	// 已有事務時加入
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		outerTx, _ := GormTxFrom(ctx)
+		return uow.Do(ctx, func(ctx context.Context) error {
+			tx, ok := GormTxFrom(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, outerTx, tx)
+			return nil
+		}, WithPropagation(PropagationSupports))
+	})
+	assert.NoError(t, err)
+}
+
+func TestGormUow_Do_PropagationNever(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	called := false
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		called = true
+		_, ok := GormTxFrom(ctx)
+		assert.False(t, ok)
+		return nil
+	}, WithPropagation(PropagationNever))
+	assert.NoError(t, err)
+	assert.True(t, called)
+
+	// 已有事務時返回 ErrTxExists
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		return uow.Do(ctx, func(ctx context.Context) error {
+			t.Fatal("should not be called")
+			return nil
+		}, WithPropagation(PropagationNever))
+	})
+	assert.ErrorIs(t, err, ErrTxExists)
+}
+
+func TestGormUow_Do_WithOptions(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		// 回調中可以讀取事務選項
+		o, ok := OptionsFrom(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, sql.LevelSerializable, o.Isolation)
+		assert.True(t, o.ReadOnly)
+		assert.True(t, IsReadOnly(ctx))
+
+		// SAVEPOINT 沿用外層事務的選項
+		return uow.Do(ctx, func(ctx context.Context) error {
+			assert.True(t, IsReadOnly(ctx))
+			return nil
+		})
+	}, WithIsolation(sql.LevelSerializable), WithReadOnly())
+	assert.NoError(t, err)
+
+	// 事務外沒有選項
+	_, ok := OptionsFrom(ctx)
+	assert.False(t, ok)
+	assert.False(t, IsReadOnly(ctx))
+}
+
+func TestGormUow_Do_OptionsConflict(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	for _, propagation := range []Propagation{PropagationNested, PropagationRequired, PropagationSupports} {
+		called := false
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			// 隔離級別與外層不同
+			err := uow.Do(ctx, func(ctx context.Context) error {
+				called = true
+				return nil
+			}, WithPropagation(propagation), WithIsolation(sql.LevelSerializable))
+			assert.ErrorIs(t, err, ErrOptionsConflict)
+
+			// 外層不是只讀
+			err = uow.Do(ctx, func(ctx context.Context) error {
+				called = true
+				return nil
+			}, WithPropagation(propagation), WithReadOnly())
+			assert.ErrorIs(t, err, ErrOptionsConflict)
+
+			// 與外層一致或不指定時可以加入
+			return uow.Do(ctx, func(ctx context.Context) error {
+				return nil
+			}, WithPropagation(propagation), WithIsolation(sql.LevelReadCommitted))
+		}, WithIsolation(sql.LevelReadCommitted))
+		assert.NoError(t, err, propagation)
+		assert.False(t, called, propagation)
+	}
+
+	// 外層只讀時內層可以要求只讀
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		return uow.Do(ctx, func(ctx context.Context) error {
+			return nil
+		}, WithPropagation(PropagationRequired), WithReadOnly())
+	}, WithReadOnly())
+	assert.NoError(t, err)
+}
+
+func TestGormUow_Do_WithOptions_GinContext(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+
+	err := uow.Do(c, func(ctx context.Context) error {
+		assert.True(t, IsReadOnly(ctx))
+		return nil
+	}, WithReadOnly())
+	assert.NoError(t, err)
+
+	// 事務結束後 gin.Context 中的選項被恢復
+	assert.False(t, IsReadOnly(c))
+}
+
+func TestGormUow_Do_WithTimeout(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+
+		tx, _ := GormTxFrom(ctx)
+		_, ok = tx.Statement.Context.Deadline()
+		assert.True(t, ok)
+		return nil
+	}, WithTimeout(time.Second))
+	assert.NoError(t, err)
+}
+
+func TestGormUow_Do_WithTimeout_Exceeded(t *testing.T) {
+	db := setupTestFileDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		tx, _ := GormTxFrom(ctx)
+		return tx.Exec("INSERT INTO test_users (name) VALUES (?)", "timeout").Error
+	}, WithTimeout(10*time.Millisecond))
+	assert.Error(t, err)
+
+	assert.Equal(t, int64(0), countUsers(t, db, "timeout"))
+}
@@ -0,0 +1,107 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/irvingos/go-tools/logx"
+)
+
+type hooks struct {
+	mu            sync.Mutex
+	afterCommit   []func(ctx context.Context)
+	afterRollback []func(ctx context.Context)
+}
+
+// ErrUnmanagedTx 表示 ctx 中的事务是通过 WithGormTx 直接绑定的，而不是由 Uow 开启的，无法得知其提交或回滚的时机
+var ErrUnmanagedTx = errors.New("tx: transaction is not managed by uow")
+
+// AfterCommit 注册在当前事务提交后执行的回调；不在事务中时立即执行。
+// ctx 中的事务不是由 Uow 开启时返回 ErrUnmanagedTx，回调不会执行
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) error {
+	st, err := hookState(ctx)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		runHook(ctx, fn)
+		return nil
+	}
+	st.hooks.mu.Lock()
+	st.hooks.afterCommit = append(st.hooks.afterCommit, fn)
+	st.hooks.mu.Unlock()
+	return nil
+}
+
+// AfterRollback 注册在当前事务（或 SAVEPOINT）回滚后执行的回调；不在事务中时不会执行。
+// ctx 中的事务不是由 Uow 开启时返回 ErrUnmanagedTx
+func AfterRollback(ctx context.Context, fn func(ctx context.Context)) error {
+	st, err := hookState(ctx)
+	if err != nil || st == nil {
+		return err
+	}
+	st.hooks.mu.Lock()
+	st.hooks.afterRollback = append(st.hooks.afterRollback, fn)
+	st.hooks.mu.Unlock()
+	return nil
+}
+
+// hookState 返回 Uow 维护的事务状态，不在事务中时返回 nil。
+// 只检查默认连接上的事务，NewMultiUow 开启的事务总是带有状态
+func hookState(ctx context.Context) (*state, error) {
+	if st, ok := stateFrom(ctx); ok {
+		if st.unmanaged {
+			return nil, ErrUnmanagedTx
+		}
+		return st, nil
+	}
+	if _, ok := GormTxFrom(ctx); ok {
+		return nil, ErrUnmanagedTx
+	}
+	return nil, nil
+}
+
+// mergeInto 在 SAVEPOINT 释放后把回调交给外层事务，由外层决定最终执行哪一组
+func (h *hooks) mergeInto(parent *hooks) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	parent.mu.Lock()
+	defer parent.mu.Unlock()
+	parent.afterCommit = append(parent.afterCommit, h.afterCommit...)
+	parent.afterRollback = append(parent.afterRollback, h.afterRollback...)
+}
+
+func (h *hooks) runAfterCommit(ctx context.Context) {
+	h.mu.Lock()
+	fns := h.afterCommit
+	h.mu.Unlock()
+	for _, fn := range fns {
+		runHook(ctx, fn)
+	}
+}
+
+func (h *hooks) runAfterRollback(ctx context.Context) {
+	h.mu.Lock()
+	fns := h.afterRollback
+	h.mu.Unlock()
+	for _, fn := range fns {
+		runHook(ctx, fn)
+	}
+}
+
+// runHook 隔离单个回调的 panic，不影响后续回调和事务结果
+func runHook(ctx context.Context, fn func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			logx.WithContext(ctx).
+				WithField(logx.FieldEvent, "tx_hook_panic").
+				WithField(logx.FieldRecover, fmt.Sprintf("%v", r)).
+				WithField(logx.FieldStack, string(debug.Stack())).
+				Error()
+		}
+	}()
+	fn(ctx)
+}
@@ -0,0 +1,206 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAfterCommit_RunAfterCommit(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	var calls []string
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func(ctx context.Context) {
+			// 回調執行時事務已提交，並且 ctx 中不再有 tx
+			_, ok := GormTxFrom(ctx)
+			assert.False(t, ok)
+			assert.Equal(t, int64(1), countUsers(t, db, "hook"))
+			calls = append(calls, "first")
+		})
+		AfterCommit(ctx, func(ctx context.Context) {
+			calls = append(calls, "second")
+		})
+		AfterRollback(ctx, func(ctx context.Context) {
+			calls = append(calls, "rollback")
+		})
+
+		// 提交前不執行
+		assert.Empty(t, calls)
+
+		tx, _ := GormTxFrom(ctx)
+		return tx.Exec("INSERT INTO test_users (name) VALUES (?)", "hook").Error
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, calls)
+}
+
+func TestAfterRollback_RunAfterRollback(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	var calls []string
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func(ctx context.Context) {
+			calls = append(calls, "commit")
+		})
+		AfterRollback(ctx, func(ctx context.Context) {
+			calls = append(calls, "rollback")
+		})
+		return errors.New("test error")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"rollback"}, calls)
+}
+
+func TestAfterRollback_RunOnPanic(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	var calls []string
+	assert.Panics(t, func() {
+		_ = uow.Do(ctx, func(ctx context.Context) error {
+			AfterRollback(ctx, func(ctx context.Context) {
+				calls = append(calls, "rollback")
+			})
+			panic("test panic")
+		})
+	})
+	assert.Equal(t, []string{"rollback"}, calls)
+}
+
+func TestAfterCommit_OutsideTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	// 不在事務中時 AfterCommit 立即執行，AfterRollback 不執行
+	var calls []string
+	AfterCommit(ctx, func(ctx context.Context) {
+		calls = append(calls, "commit")
+	})
+	AfterRollback(ctx, func(ctx context.Context) {
+		calls = append(calls, "rollback")
+	})
+	assert.Equal(t, []string{"commit"}, calls)
+}
+
+func TestAfterCommit_UnmanagedTx(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+
+	// WithGormTx 直接綁定的事務無法得知提交時機，返回錯誤且不執行回調
+	manual := db.Begin()
+	defer manual.Rollback()
+	ctx := WithGormTx(context.Background(), manual)
+
+	var calls []string
+	assert.ErrorIs(t, AfterCommit(ctx, func(ctx context.Context) {
+		calls = append(calls, "commit")
+	}), ErrUnmanagedTx)
+	assert.ErrorIs(t, AfterRollback(ctx, func(ctx context.Context) {
+		calls = append(calls, "rollback")
+	}), ErrUnmanagedTx)
+
+	// 加入該事務或在其上建立保存點時同樣返回錯誤
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		return AfterCommit(ctx, func(ctx context.Context) {
+			calls = append(calls, "joined")
+		})
+	}, WithPropagation(PropagationRequired))
+	assert.ErrorIs(t, err, ErrUnmanagedTx)
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		return uow.Do(ctx, func(ctx context.Context) error {
+			return AfterCommit(ctx, func(ctx context.Context) {
+				calls = append(calls, "savepoint")
+			})
+		})
+	})
+	assert.ErrorIs(t, err, ErrUnmanagedTx)
+	assert.Empty(t, calls)
+}
+
+func TestAfterCommit_PanicIsolated(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	var calls []string
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func(ctx context.Context) {
+			panic("hook panic")
+		})
+		AfterCommit(ctx, func(ctx context.Context) {
+			calls = append(calls, "second")
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"second"}, calls)
+}
+
+func TestAfterCommit_Savepoint(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	var calls []string
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		// 保存點釋放後，回調等待外層事務提交
+		_ = uow.Do(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func(ctx context.Context) {
+				calls = append(calls, "released_commit")
+			})
+			return nil
+		})
+		assert.Empty(t, calls)
+
+		// 保存點回滾時，AfterRollback 立即執行，AfterCommit 被丟棄
+		_ = uow.Do(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func(ctx context.Context) {
+				calls = append(calls, "rolled_back_commit")
+			})
+			AfterRollback(ctx, func(ctx context.Context) {
+				calls = append(calls, "rolled_back_rollback")
+			})
+			return errors.New("inner error")
+		})
+		assert.Equal(t, []string{"rolled_back_rollback"}, calls)
+
+		AfterCommit(ctx, func(ctx context.Context) {
+			calls = append(calls, "outer_commit")
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rolled_back_rollback", "released_commit", "outer_commit"}, calls)
+}
+
+func TestAfterCommit_GinContext(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+
+	var calls []string
+	err := uow.Do(c, func(ctx context.Context) error {
+		AfterCommit(ctx, func(ctx context.Context) {
+			calls = append(calls, "commit")
+		})
+		assert.Empty(t, calls)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"commit"}, calls)
+
+	// 事務結束後 gin.Context 中不再保留事務狀態
+	AfterCommit(c, func(ctx context.Context) {
+		calls = append(calls, "immediate")
+	})
+	assert.Equal(t, []string{"commit", "immediate"}, calls)
+}
@@ -0,0 +1,184 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNoTx = errors.New("tx: no transaction in context")
+
+// Locker 提供按 key 加锁的能力。
+// ctx 中有事务时加事务级锁，随事务提交或回滚自动释放，返回的 unlock 为空操作；
+// 否则加会话级锁，必须调用 unlock 释放
+type Locker interface {
+	Lock(ctx context.Context, key string) (unlock func() error, err error)
+	// TryLock 不等待，锁已被占用时返回 ok 为 false
+	TryLock(ctx context.Context, key string) (unlock func() error, ok bool, err error)
+}
+
+// HashKey 将字符串 key 映射为 advisory lock 使用的 int64
+func HashKey(key string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return int64(h.Sum64())
+}
+
+func noopUnlock() error {
+	return nil
+}
+
+// NewPgLocker 基于 Postgres advisory lock 实现 Locker
+func NewPgLocker(db *gorm.DB) Locker {
+	return &pgLocker{db: db}
+}
+
+type pgLocker struct {
+	db *gorm.DB
+}
+
+// Lock implements Locker.
+func (l *pgLocker) Lock(ctx context.Context, key string) (func() error, error) {
+	k := HashKey(key)
+	if t, ok := GormTxFrom(ctx); ok {
+		return noopUnlock, t.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?)", k).Error
+	}
+
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// 会话级锁绑定在连接上，加锁和解锁必须使用同一个连接
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", k); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", k)
+		return err
+	}, nil
+}
+
+// TryLock implements Locker.
+func (l *pgLocker) TryLock(ctx context.Context, key string) (func() error, bool, error) {
+	k := HashKey(key)
+	if t, ok := GormTxFrom(ctx); ok {
+		var locked bool
+		if err := t.WithContext(ctx).Raw("SELECT pg_try_advisory_xact_lock(?)", k).Scan(&locked).Error; err != nil {
+			return nil, false, err
+		}
+		return noopUnlock, locked, nil
+	}
+
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", k).Scan(&locked); err != nil || !locked {
+		_ = conn.Close()
+		return nil, false, err
+	}
+	return func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", k)
+		return err
+	}, true, nil
+}
+
+// NewMemoryLocker 返回进程内的 Locker，用于单元测试或 sqlite 等不支持 advisory lock 的场景。
+// 与 advisory lock 不同，同一个 key 不可重入
+func NewMemoryLocker() Locker {
+	return &memoryLocker{locks: make(map[int64]chan struct{})}
+}
+
+type memoryLocker struct {
+	mu    sync.Mutex
+	locks map[int64]chan struct{}
+}
+
+// Lock implements Locker.
+func (l *memoryLocker) Lock(ctx context.Context, key string) (func() error, error) {
+	ch := l.lockOf(key)
+	select {
+	case ch <- struct{}{}:
+		return l.unlockFor(ctx, ch), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// TryLock implements Locker.
+func (l *memoryLocker) TryLock(ctx context.Context, key string) (func() error, bool, error) {
+	ch := l.lockOf(key)
+	select {
+	case ch <- struct{}{}:
+		return l.unlockFor(ctx, ch), true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+func (l *memoryLocker) lockOf(key string) chan struct{} {
+	k := HashKey(key)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ch, ok := l.locks[k]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		l.locks[k] = ch
+	}
+	return ch
+}
+
+// unlockFor 在 Uow 事务中时模拟事务级锁，通过事务回调释放；
+// 事务不是由 Uow 开启时无法注册回调，与不在事务中一样需要调用 unlock 释放
+func (l *memoryLocker) unlockFor(ctx context.Context, ch chan struct{}) func() error {
+	var once sync.Once
+	release := func() {
+		once.Do(func() { <-ch })
+	}
+
+	if st, err := hookState(ctx); err == nil && st != nil {
+		_ = AfterCommit(ctx, func(context.Context) { release() })
+		_ = AfterRollback(ctx, func(context.Context) { release() })
+		return noopUnlock
+	}
+	return func() error {
+		release()
+		return nil
+	}
+}
+
+// ForUpdate 返回当前事务上带 FOR UPDATE 的 *gorm.DB，options 可以是
+// clause.LockingOptionsSkipLocked 或 clause.LockingOptionsNoWait；不在事务中时返回 ErrNoTx
+func ForUpdate(ctx context.Context, options ...string) (*gorm.DB, error) {
+	return locking(ctx, clause.LockingStrengthUpdate, options)
+}
+
+// ForShare 与 ForUpdate 相同，但加共享锁
+func ForShare(ctx context.Context, options ...string) (*gorm.DB, error) {
+	return locking(ctx, clause.LockingStrengthShare, options)
+}
+
+func locking(ctx context.Context, strength string, options []string) (*gorm.DB, error) {
+	t, ok := GormTxFrom(ctx)
+	if !ok {
+		return nil, ErrNoTx
+	}
+	return t.Clauses(clause.Locking{Strength: strength, Options: strings.Join(options, " ")}), nil
+}
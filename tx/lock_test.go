@@ -0,0 +1,129 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func TestHashKey(t *testing.T) {
+	assert.Equal(t, HashKey("order:1"), HashKey("order:1"))
+	assert.NotEqual(t, HashKey("order:1"), HashKey("order:2"))
+}
+
+func TestMemoryLocker_Lock(t *testing.T) {
+	locker := NewMemoryLocker()
+	ctx := context.Background()
+
+	unlock, err := locker.Lock(ctx, "job")
+	require.NoError(t, err)
+
+	// 鎖被佔用時 TryLock 失敗，其他 key 不受影響
+	_, ok, err := locker.TryLock(ctx, "job")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	unlockOther, ok, err := locker.TryLock(ctx, "other")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, unlockOther())
+
+	// Lock 阻塞直到鎖被釋放
+	acquired := make(chan struct{})
+	go func() {
+		unlock, err := locker.Lock(ctx, "job")
+		assert.NoError(t, err)
+		close(acquired)
+		_ = unlock()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Lock should block while the lock is held")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	assert.NoError(t, unlock())
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Lock should be acquired after unlock")
+	}
+}
+
+func TestMemoryLocker_Lock_ContextCanceled(t *testing.T) {
+	locker := NewMemoryLocker()
+
+	unlock, err := locker.Lock(context.Background(), "job")
+	require.NoError(t, err)
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = locker.Lock(ctx, "job")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMemoryLocker_TransactionScoped(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	locker := NewMemoryLocker()
+	ctx := context.Background()
+
+	for _, txErr := range []error{nil, errors.New("test error")} {
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			unlock, err := locker.Lock(ctx, "job")
+			require.NoError(t, err)
+
+			// 事務級鎖不能手動釋放
+			assert.NoError(t, unlock())
+			_, ok, _ := locker.TryLock(context.Background(), "job")
+			assert.False(t, ok)
+			return txErr
+		})
+		assert.Equal(t, txErr, err)
+
+		// 事務提交或回滾後自動釋放
+		unlock, ok, err := locker.TryLock(ctx, "job")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.NoError(t, unlock())
+	}
+}
+
+func TestForUpdate(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	// 不在事務中
+	_, err := ForUpdate(ctx)
+	assert.ErrorIs(t, err, ErrNoTx)
+	_, err = ForShare(ctx)
+	assert.ErrorIs(t, err, ErrNoTx)
+
+	assert.NoError(t, db.Exec("INSERT INTO test_users (name) VALUES (?)", "locked").Error)
+
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		locked, err := ForUpdate(ctx, clause.LockingOptionsSkipLocked)
+		require.NoError(t, err)
+
+		// 生成的 SQL 帶有行鎖
+		stmt := locked.Session(&gorm.Session{DryRun: true}).Table("test_users").Find(&[]map[string]any{}).Statement
+		locking, ok := stmt.Clauses["FOR"].Expression.(clause.Locking)
+		assert.True(t, ok)
+		assert.Equal(t, clause.LockingStrengthUpdate, locking.Strength)
+		assert.Equal(t, clause.LockingOptionsSkipLocked, locking.Options)
+
+		// sqlite 忽略行鎖，查詢仍可正常執行
+		var name string
+		return locked.Table("test_users").Select("name").Limit(1).Scan(&name).Error
+	})
+	assert.NoError(t, err)
+}
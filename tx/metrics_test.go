@@ -0,0 +1,51 @@
+package tx
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/irvingos/go-tools/gormx"
+	"github.com/irvingos/go-tools/logx"
+	"github.com/irvingos/go-tools/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/logger"
+)
+
+func TestMetricsPlugin(t *testing.T) {
+	db := setupTestDBWithTable(t)
+	require.NoError(t, db.Use(gormx.MetricsPlugin{}))
+	repo := NewRepo[testUser](db)
+	ctx := context.Background()
+
+	creates := metrics.DBQueryDuration.Count("create", "test_users")
+	queries := metrics.DBQueryDuration.Count("query", "test_users")
+
+	require.NoError(t, repo.Create(ctx, &testUser{Name: "u1"}))
+	_, err := repo.FindByID(ctx, 1)
+	require.NoError(t, err)
+	_, err = repo.FindByID(ctx, 2)
+	assert.Error(t, err)
+
+	assert.Equal(t, creates+1, metrics.DBQueryDuration.Count("create", "test_users"))
+	assert.Equal(t, queries+2, metrics.DBQueryDuration.Count("query", "test_users"))
+}
+
+func TestDBLogger_SlowQueries(t *testing.T) {
+	logx.Init(&logx.Options{Output: io.Discard})
+	t.Cleanup(func() { logx.Init(&logx.Options{}) })
+
+	db := setupTestDBWithTable(t)
+	slow := metrics.DBSlowQueriesTotal.Value()
+
+	// 與慢查詢日誌使用同一個閾值，Silent 時也統計
+	db.Logger = logx.NewDBLogger(&logx.DBLoggerOptions{SlowSQLThreshold: time.Nanosecond}).LogMode(logger.Silent)
+	require.NoError(t, db.Create(&testUser{Name: "u1"}).Error)
+	assert.Equal(t, slow+1, metrics.DBSlowQueriesTotal.Value())
+
+	db.Logger = logx.NewDBLogger(&logx.DBLoggerOptions{SlowSQLThreshold: time.Hour})
+	require.NoError(t, db.Create(&testUser{Name: "u2"}).Error)
+	assert.Equal(t, slow+1, metrics.DBSlowQueriesTotal.Value())
+}
@@ -0,0 +1,152 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type NamedDB struct {
+	Name string
+	DB   *gorm.DB
+}
+
+// CommitError 表示前面的连接已经提交、后面的连接提交失败，已提交的数据无法回滚
+type CommitError struct {
+	Name       string
+	Committed  []string
+	RolledBack []string
+	Err        error
+}
+
+func (e *CommitError) Error() string {
+	return fmt.Sprintf("tx: commit %q failed after %v committed, %v rolled back: %v", e.Name, e.Committed, e.RolledBack, e.Err)
+}
+
+func (e *CommitError) Unwrap() error {
+	return e.Err
+}
+
+// NewMultiUow 在多个命名连接上同时开启事务，回调结束后按 dbs 的顺序依次提交。
+// 名字为空的连接写入默认位置，可以直接用 GormTxFrom 和未命名的 BaseRepo 访问
+func NewMultiUow(dbs ...NamedDB) Uow {
+	return &multiUow{dbs: dbs}
+}
+
+type multiUow struct {
+	dbs []NamedDB
+}
+
+// Do implements Uow.
+// ctx 中已经有全部连接的事务时直接加入外层事务，其余传播方式不适用于多连接场景。
+// 只有部分连接有事务时返回 ErrTxExists：重新开启会覆盖外层事务，外层回滚时无法撤销这里的提交
+func (u *multiUow) Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	o := newOptions(opts)
+	switch n := u.countTx(ctx); {
+	case n > 0 && n == len(u.dbs):
+		if err := checkJoin(ctx, o); err != nil {
+			return err
+		}
+		return fn(ctx)
+	case n > 0:
+		return fmt.Errorf("%w: %d of %d connections are already in a transaction", ErrTxExists, n, len(u.dbs))
+	}
+
+	dbCtx, cancel := withTimeout(ctx, o.Timeout)
+	defer cancel()
+
+	txs := make([]*gorm.DB, 0, len(u.dbs))
+	for _, d := range u.dbs {
+		t := d.DB.WithContext(dbCtx).Begin(o.txOptions())
+		if t.Error != nil {
+			u.rollback(txs, 0)
+			return fmt.Errorf("tx: begin %q: %w", d.Name, t.Error)
+		}
+		txs = append(txs, t)
+	}
+
+	st := &state{options: o, hooks: &hooks{}}
+	panicked := true
+	defer func() {
+		if panicked {
+			u.rollback(txs, 0)
+			st.hooks.runAfterRollback(ctx)
+		}
+	}()
+
+	err := u.run(ctx, dbCtx, txs, st, fn)
+	panicked = false
+	if err != nil {
+		u.rollback(txs, 0)
+		st.hooks.runAfterRollback(ctx)
+		return err
+	}
+
+	for i, t := range txs {
+		if err := t.Commit().Error; err != nil {
+			cErr := &CommitError{Name: u.dbs[i].Name, Err: err}
+			for _, d := range u.dbs[:i] {
+				cErr.Committed = append(cErr.Committed, d.Name)
+			}
+			cErr.RolledBack = u.rollback(txs, i+1)
+			// 部分提交同样视为失败
+			st.hooks.runAfterRollback(ctx)
+			return cErr
+		}
+	}
+	st.hooks.runAfterCommit(ctx)
+	return nil
+}
+
+// countTx 返回 ctx 中已有事务的连接数
+func (u *multiUow) countTx(ctx context.Context) int {
+	n := 0
+	for _, d := range u.dbs {
+		if _, ok := GormTxFromNamed(ctx, d.Name); ok {
+			n++
+		}
+	}
+	return n
+}
+
+// rollback 尽力回滚 txs[from:]，返回回滚成功的连接名
+func (u *multiUow) rollback(txs []*gorm.DB, from int) []string {
+	var rolledBack []string
+	for i := from; i < len(txs); i++ {
+		if txs[i].Rollback().Error == nil {
+			rolledBack = append(rolledBack, u.dbs[i].Name)
+		}
+	}
+	return rolledBack
+}
+
+func (u *multiUow) run(ctx, dbCtx context.Context, txs []*gorm.DB, st *state, fn func(ctx context.Context) error) error {
+	gCtx, ok := ctx.(*gin.Context)
+	if !ok {
+		txCtx := dbCtx
+		for i, t := range txs {
+			txCtx = WithGormTxNamed(txCtx, u.dbs[i].Name, t)
+		}
+		return fn(withState(txCtx, st))
+	}
+
+	// gin.Context 是原地修改的，结束后恢复外层的值
+	prev := make(map[any]any, len(txs)+1)
+	for _, d := range u.dbs {
+		prev[txKey(d.Name)], _ = gCtx.Get(txKey(d.Name))
+	}
+	prev[stateKey{}], _ = gCtx.Get(stateKey{})
+	defer func() {
+		for k, v := range prev {
+			gCtx.Set(k, v)
+		}
+	}()
+
+	for i, t := range txs {
+		WithGormTxNamed(gCtx, u.dbs[i].Name, t)
+	}
+	withState(gCtx, st)
+	return fn(gCtx)
+}
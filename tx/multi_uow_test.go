@@ -0,0 +1,165 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestWithGormTxNamed(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	main := db.Begin()
+	audit := db.Begin()
+
+	ctx = WithGormTx(ctx, main)
+	ctx = WithGormTxNamed(ctx, "audit", audit)
+
+	retrieved, ok := GormTxFromNamed(ctx, "audit")
+	assert.True(t, ok)
+	assert.Equal(t, audit, retrieved)
+
+	// 空名即默認連接
+	retrieved, ok = GormTxFromNamed(ctx, "")
+	assert.True(t, ok)
+	assert.Equal(t, main, retrieved)
+
+	_, ok = GormTxFromNamed(ctx, "other")
+	assert.False(t, ok)
+}
+
+func TestBaseRepo_DBFrom_Named(t *testing.T) {
+	db := setupTestDB(t)
+	mainRepo := NewBaseRepo(db)
+	auditRepo := NewBaseRepo(db, WithName("audit"))
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+
+	audit := db.Begin()
+	WithGormTxNamed(c, "audit", audit)
+
+	assert.Equal(t, audit, auditRepo.DBFrom(c))
+	assert.Equal(t, db.Statement.ConnPool, mainRepo.DBFrom(c).Statement.ConnPool)
+}
+
+func TestMultiUow_Do_Commit(t *testing.T) {
+	mainDB := setupTestFileDBWithTable(t)
+	auditDB := setupTestFileDBWithTable(t)
+	mainRepo := NewBaseRepo(mainDB)
+	auditRepo := NewBaseRepo(auditDB, WithName("audit"))
+	uow := NewMultiUow(NamedDB{DB: mainDB}, NamedDB{Name: "audit", DB: auditDB})
+	ctx := context.Background()
+
+	var committed bool
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		assert.NoError(t, mainRepo.DBFrom(ctx).Exec("INSERT INTO test_users (name) VALUES (?)", "main").Error)
+		assert.NoError(t, auditRepo.DBFrom(ctx).Exec("INSERT INTO test_users (name) VALUES (?)", "audit").Error)
+		AfterCommit(ctx, func(ctx context.Context) {
+			committed = true
+		})
+
+		// 嵌套調用加入外層事務
+		return uow.Do(ctx, func(ctx context.Context) error {
+			return auditRepo.DBFrom(ctx).Exec("INSERT INTO test_users (name) VALUES (?)", "nested").Error
+		})
+	})
+	assert.NoError(t, err)
+	assert.True(t, committed)
+
+	assert.Equal(t, int64(1), countUsers(t, mainDB, "main"))
+	assert.Equal(t, int64(0), countUsers(t, mainDB, "audit"))
+	assert.Equal(t, int64(1), countUsers(t, auditDB, "audit"))
+	assert.Equal(t, int64(1), countUsers(t, auditDB, "nested"))
+}
+
+func TestMultiUow_Do_Rollback(t *testing.T) {
+	mainDB := setupTestFileDBWithTable(t)
+	auditDB := setupTestFileDBWithTable(t)
+	uow := NewMultiUow(NamedDB{Name: "main", DB: mainDB}, NamedDB{Name: "audit", DB: auditDB})
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+
+	testErr := errors.New("test error")
+	err := uow.Do(c, func(ctx context.Context) error {
+		main, _ := GormTxFromNamed(ctx, "main")
+		audit, _ := GormTxFromNamed(ctx, "audit")
+		assert.NoError(t, main.Exec("INSERT INTO test_users (name) VALUES (?)", "main").Error)
+		assert.NoError(t, audit.Exec("INSERT INTO test_users (name) VALUES (?)", "audit").Error)
+		return testErr
+	})
+	assert.ErrorIs(t, err, testErr)
+
+	assert.Equal(t, int64(0), countUsers(t, mainDB, "main"))
+	assert.Equal(t, int64(0), countUsers(t, auditDB, "audit"))
+
+	// gin.Context 中的事務已恢復
+	_, ok := GormTxFromNamed(c, "main")
+	assert.False(t, ok)
+}
+
+func TestMultiUow_Do_PartialTx(t *testing.T) {
+	mainDB := setupTestFileDBWithTable(t)
+	auditDB := setupTestFileDBWithTable(t)
+	multi := NewMultiUow(NamedDB{DB: mainDB}, NamedDB{Name: "audit", DB: auditDB})
+
+	// 外層只有默認連接的事務時不能重新開啟，否則會覆蓋外層事務
+	var called bool
+	err := NewGormUow(mainDB).Do(context.Background(), func(ctx context.Context) error {
+		return multi.Do(ctx, func(ctx context.Context) error {
+			called = true
+			return nil
+		})
+	})
+	assert.ErrorIs(t, err, ErrTxExists)
+	assert.False(t, called)
+}
+
+func TestMultiUow_Do_CommitError(t *testing.T) {
+	mainDB := setupTestFileDBWithTable(t)
+	lastDB := setupTestFileDBWithTable(t)
+
+	// 延遲外鍵約束在提交時才檢查，用於模擬後面的連接提交失敗
+	auditDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")+"?_foreign_keys=on"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, auditDB.Exec("CREATE TABLE parents (id INTEGER PRIMARY KEY)").Error)
+	require.NoError(t, auditDB.Exec("CREATE TABLE children (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parents(id) DEFERRABLE INITIALLY DEFERRED)").Error)
+
+	uow := NewMultiUow(
+		NamedDB{Name: "main", DB: mainDB},
+		NamedDB{Name: "audit", DB: auditDB},
+		NamedDB{Name: "last", DB: lastDB},
+	)
+	ctx := context.Background()
+
+	var rolledBack bool
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		main, _ := GormTxFromNamed(ctx, "main")
+		audit, _ := GormTxFromNamed(ctx, "audit")
+		last, _ := GormTxFromNamed(ctx, "last")
+		assert.NoError(t, main.Exec("INSERT INTO test_users (name) VALUES (?)", "main").Error)
+		assert.NoError(t, audit.Exec("INSERT INTO children (parent_id) VALUES (?)", 1).Error)
+		assert.NoError(t, last.Exec("INSERT INTO test_users (name) VALUES (?)", "last").Error)
+		AfterRollback(ctx, func(ctx context.Context) {
+			rolledBack = true
+		})
+		return nil
+	})
+
+	var cErr *CommitError
+	require.ErrorAs(t, err, &cErr)
+	assert.Equal(t, "audit", cErr.Name)
+	assert.Equal(t, []string{"main"}, cErr.Committed)
+	assert.Equal(t, []string{"last"}, cErr.RolledBack)
+	assert.Contains(t, err.Error(), "FOREIGN KEY")
+	assert.True(t, rolledBack)
+
+	assert.Equal(t, int64(1), countUsers(t, mainDB, "main"))
+	assert.Equal(t, int64(0), countUsers(t, lastDB, "last"))
+}
@@ -0,0 +1,103 @@
+package tx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrTxExists = errors.New("tx: transaction already exists")
+	// ErrOptionsConflict 表示加入已有事务（包括 SAVEPOINT）时要求的隔离级别或只读与外层事务不一致
+	ErrOptionsConflict = errors.New("tx: options conflict with the existing transaction")
+)
+
+// Propagation 决定 Uow.Do 遇到 ctx 中已有事务时的行为
+type Propagation int
+
+const (
+	// PropagationNested 已有事务时开启 SAVEPOINT，内层失败只回滚到保存点；没有事务时开启新事务
+	PropagationNested Propagation = iota
+	// PropagationRequired 已有事务时直接加入，内层错误原样返回给外层；没有事务时开启新事务
+	PropagationRequired
+	// PropagationRequiresNew 总是在新的连接上开启独立事务，与外层事务互不影响
+	PropagationRequiresNew
+	// PropagationSupports 已有事务时加入，没有事务时以非事务方式执行
+	PropagationSupports
+	// PropagationNever 以非事务方式执行，已有事务时返回 ErrTxExists
+	PropagationNever
+)
+
+type Options struct {
+	Propagation Propagation
+	// Isolation 和 ReadOnly 只在开启新事务时生效，加入已有事务或开启 SAVEPOINT 时沿用外层事务的设置，
+	// 要求的隔离级别与外层不同、或要求只读而外层不是只读时返回 ErrOptionsConflict
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+	// Timeout 大于 0 时为事务派生带 deadline 的 ctx，开启新事务和 SAVEPOINT 时生效；
+	// 直接加入已有事务（PropagationRequired、PropagationSupports）时不生效，由外层事务的 Timeout 决定
+	Timeout time.Duration
+}
+
+func (o Options) txOptions() *sql.TxOptions {
+	if o.Isolation == sql.LevelDefault && !o.ReadOnly {
+		return nil
+	}
+	return &sql.TxOptions{Isolation: o.Isolation, ReadOnly: o.ReadOnly}
+}
+
+type Option func(o *Options)
+
+func newOptions(opts []Option) Options {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func WithPropagation(p Propagation) Option {
+	return func(o *Options) {
+		o.Propagation = p
+	}
+}
+
+// WithIsolation 设置新事务的隔离级别，加入已有事务时必须与外层一致，否则返回 ErrOptionsConflict
+func WithIsolation(level sql.IsolationLevel) Option {
+	return func(o *Options) {
+		o.Isolation = level
+	}
+}
+
+// WithReadOnly 开启只读事务，加入已有事务时外层必须也是只读，否则返回 ErrOptionsConflict
+func WithReadOnly() Option {
+	return func(o *Options) {
+		o.ReadOnly = true
+	}
+}
+
+// WithTimeout 设置事务的超时时间，直接加入已有事务时不生效
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+// checkJoin 在加入 ctx 中已有的事务前检查隔离级别和只读是否与外层一致。
+// 外层事务不是由 Uow 开启时无法得知其选项，不做检查
+func checkJoin(ctx context.Context, o Options) error {
+	st, ok := stateFrom(ctx)
+	if !ok || st.unmanaged {
+		return nil
+	}
+	outer := st.options
+	if o.Isolation != sql.LevelDefault && o.Isolation != outer.Isolation {
+		return fmt.Errorf("%w: isolation %s, outer %s", ErrOptionsConflict, o.Isolation, outer.Isolation)
+	}
+	if o.ReadOnly && !outer.ReadOnly {
+		return fmt.Errorf("%w: read only, outer read write", ErrOptionsConflict)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+
+	"github.com/irvingos/go-tools/ctxkey"
+	"gorm.io/gorm"
+)
+
+type ReplicaPolicy int
+
+const (
+	ReplicaRoundRobin ReplicaPolicy = iota
+	ReplicaRandom
+	// ReplicaPrimaryOnly 忽略从库，所有读写都走主库
+	ReplicaPrimaryOnly
+)
+
+func WithReplicas(replicas ...*gorm.DB) RepoOption {
+	return func(b *BaseRepo) {
+		r := b.resolverOrNew()
+		r.replicas = append(r.replicas, replicas...)
+	}
+}
+
+func WithReplicaPolicy(p ReplicaPolicy) RepoOption {
+	return func(b *BaseRepo) {
+		b.resolverOrNew().policy = p
+	}
+}
+
+type resolver struct {
+	replicas []*gorm.DB
+	policy   ReplicaPolicy
+	next     atomic.Uint64
+}
+
+func (r *resolver) pick() *gorm.DB {
+	if r == nil || len(r.replicas) == 0 {
+		return nil
+	}
+	switch r.policy {
+	case ReplicaPrimaryOnly:
+		return nil
+	case ReplicaRandom:
+		return r.replicas[rand.N(len(r.replicas))]
+	default:
+		return r.replicas[(r.next.Add(1)-1)%uint64(len(r.replicas))]
+	}
+}
+
+var primaryKey = ctxkey.New[bool]("tx.primary")
+
+// WithPrimary 标记当前请求的读操作也走主库，用于写后立即读的场景，避免读到从库的延迟数据
+func WithPrimary(ctx context.Context) context.Context {
+	return primaryKey.With(ctx, true)
+}
+
+func isPrimary(ctx context.Context) bool {
+	primary, _ := primaryKey.From(ctx)
+	return primary
+}
+
+const replicaSetting = "tx:replica"
+
+// replicaPlugin 在查询执行前把连接切换到 DBFrom 选中的从库，写操作不受影响
+type replicaPlugin struct{}
+
+func (replicaPlugin) Name() string {
+	return "tx:replica"
+}
+
+func (replicaPlugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("tx:replica_query", useReplica); err != nil {
+		return err
+	}
+	return db.Callback().Row().Before("gorm:row").Register("tx:replica_row", func(db *gorm.DB) {
+		// Raw 的 SQL 已经生成，只有 SELECT 才走从库
+		if sql := strings.TrimSpace(db.Statement.SQL.String()); sql != "" && !strings.HasPrefix(strings.ToUpper(sql), "SELECT") {
+			return
+		}
+		useReplica(db)
+	})
+}
+
+func useReplica(db *gorm.DB) {
+	v, ok := db.Statement.Settings.Load(replicaSetting)
+	if !ok {
+		return
+	}
+	if replica, ok := v.(*gorm.DB); ok {
+		db.Statement.ConnPool = replica.Statement.ConnPool
+	}
+}
+
+func registerReplicaPlugin(db *gorm.DB) {
+	if err := db.Use(replicaPlugin{}); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+		panic(err)
+	}
+}
@@ -0,0 +1,146 @@
+package tx
+
+import (
+	"context"
+
+	"github.com/irvingos/go-tools/consts"
+	"github.com/irvingos/go-tools/errorx"
+	"github.com/irvingos/go-tools/gormx"
+	"github.com/irvingos/go-tools/page"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func NewRepo[T any](db *gorm.DB, opts ...RepoOption) Repo[T] {
+	return Repo[T]{BaseRepo: NewBaseRepo(db, opts...)}
+}
+
+// Repo 在 BaseRepo 之上提供通用的 CRUD，所有方法都通过 DBFrom 感知当前事务
+type Repo[T any] struct {
+	BaseRepo
+}
+
+// FindByID 按主键查询，id 只作为参数绑定，不会被 gorm 当作 SQL 条件解析
+func (r Repo[T]) FindByID(ctx context.Context, id any) (*T, error) {
+	var t T
+	if err := r.DBFrom(ctx).Where(primaryKeyEq(id)).First(&t).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &t, nil
+}
+
+func (r Repo[T]) FindOneBy(ctx context.Context, query any, args ...any) (*T, error) {
+	var t T
+	if err := r.DBFrom(ctx).Where(query, args...).First(&t).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &t, nil
+}
+
+func (r Repo[T]) FindBy(ctx context.Context, query any, args ...any) ([]*T, error) {
+	var ts []*T
+	if err := r.DBFrom(ctx).Where(query, args...).Find(&ts).Error; err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// List 按 spec 分页查询，SortBy 必须是模型的字段名或列名，否则返回 gormx.ErrUnknownSortByField
+func (r Repo[T]) List(ctx context.Context, spec page.Spec, scopes ...func(*gorm.DB) *gorm.DB) (page.Page[T], error) {
+	var p page.Page[T]
+
+	db := r.DBFrom(ctx).Model(new(T)).Scopes(scopes...)
+	if err := db.Session(&gorm.Session{}).Count(&p.Total).Error; err != nil {
+		return p, err
+	}
+
+	order, err := r.orderBy(db, spec)
+	if err != nil {
+		return p, err
+	}
+	if order != nil {
+		db = db.Order(*order)
+	}
+	if spec.Offset > 0 {
+		db = db.Offset(spec.Offset)
+	}
+	if spec.Limit > 0 {
+		db = db.Limit(spec.Limit)
+	}
+
+	p.Data = make([]T, 0)
+	if err := db.Find(&p.Data).Error; err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+func (r Repo[T]) orderBy(db *gorm.DB, spec page.Spec) (*clause.OrderByColumn, error) {
+	if spec.SortBy == "" {
+		return nil, nil
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	f := stmt.Schema.LookUpField(spec.SortBy)
+	if f == nil || f.DBName == "" {
+		return nil, gormx.ErrUnknownSortByField
+	}
+
+	return &clause.OrderByColumn{
+		Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName},
+		Desc:   spec.SortOrder != consts.GORM_ASC,
+	}, nil
+}
+
+func (r Repo[T]) Create(ctx context.Context, t *T) error {
+	return r.DBFrom(ctx).Create(t).Error
+}
+
+func (r Repo[T]) CreateInBatches(ctx context.Context, ts []*T, batchSize int) error {
+	return r.DBFrom(ctx).CreateInBatches(ts, batchSize).Error
+}
+
+// Updates 按主键更新 t；fields 为空时只更新非零值字段，否则只更新 fields 中的字段（包括零值）
+func (r Repo[T]) Updates(ctx context.Context, t *T, fields ...string) error {
+	db := r.DBFrom(ctx).Model(t)
+	if len(fields) > 0 {
+		db = db.Select(fields)
+	}
+	return db.Updates(t).Error
+}
+
+// Delete 按主键删除，没有行被删除时返回 errorx.ErrNotFound
+func (r Repo[T]) Delete(ctx context.Context, id any) error {
+	res := r.DBFrom(ctx).Where(primaryKeyEq(id)).Delete(new(T))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errorx.ErrNotFound
+	}
+	return nil
+}
+
+func (r Repo[T]) Exists(ctx context.Context, query any, args ...any) (bool, error) {
+	var count int64
+	if err := r.DBFrom(ctx).Model(new(T)).Where(query, args...).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// primaryKeyEq 生成 "主键 = id" 条件。gorm 的内联条件（First(&t, id)、Delete(new(T), id)）
+// 会把非数字的字符串当作原始 SQL，对字符串和 UUID 主键是 SQL 注入
+func primaryKeyEq(id any) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: clause.PrimaryKey}, Value: id}
+}
+
+func notFound(err error) error {
+	if gormx.IsRecordNotFoundError(err) {
+		return errorx.Wrap(err, errorx.ErrNotFound)
+	}
+	return err
+}
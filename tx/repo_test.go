@@ -0,0 +1,200 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/irvingos/go-tools/consts"
+	"github.com/irvingos/go-tools/errorx"
+	"github.com/irvingos/go-tools/gormx"
+	"github.com/irvingos/go-tools/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type testAccount struct {
+	ID   int64
+	Name string
+	Age  int
+}
+
+func setupTestRepo(t *testing.T) (*gorm.DB, Repo[testAccount]) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&testAccount{}))
+	return db, NewRepo[testAccount](db)
+}
+
+func TestRepo_CreateAndFind(t *testing.T) {
+	_, repo := setupTestRepo(t)
+	ctx := context.Background()
+
+	a := &testAccount{Name: "alice", Age: 20}
+	require.NoError(t, repo.Create(ctx, a))
+	assert.NotZero(t, a.ID)
+
+	found, err := repo.FindByID(ctx, a.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, a, found)
+
+	found, err = repo.FindOneBy(ctx, "name = ?", "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, a.ID, found.ID)
+
+	list, err := repo.FindBy(ctx, &testAccount{Age: 20})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestRepo_NotFound(t *testing.T) {
+	_, repo := setupTestRepo(t)
+	ctx := context.Background()
+
+	// 找不到時返回包裝了 gorm.ErrRecordNotFound 的 errorx.ErrNotFound
+	_, err := repo.FindByID(ctx, 1)
+	assert.ErrorIs(t, err, errorx.ErrNotFound)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	_, err = repo.FindOneBy(ctx, "name = ?", "nobody")
+	assert.ErrorIs(t, err, errorx.ErrNotFound)
+
+	list, err := repo.FindBy(ctx, "name = ?", "nobody")
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestRepo_List(t *testing.T) {
+	_, repo := setupTestRepo(t)
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateInBatches(ctx, []*testAccount{
+		{Name: "a", Age: 30},
+		{Name: "b", Age: 10},
+		{Name: "c", Age: 20},
+		{Name: "d", Age: 40},
+	}, 2))
+
+	p, err := repo.List(ctx, page.Spec{Offset: 1, Limit: 2, SortBy: "Age", SortOrder: consts.GORM_ASC})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), p.Total)
+	require.Len(t, p.Data, 2)
+	assert.Equal(t, "c", p.Data[0].Name)
+	assert.Equal(t, "a", p.Data[1].Name)
+
+	// 使用列名排序，默認降序
+	p, err = repo.List(ctx, page.Spec{Limit: 1, SortBy: "age"})
+	assert.NoError(t, err)
+	require.Len(t, p.Data, 1)
+	assert.Equal(t, "d", p.Data[0].Name)
+
+	// scopes 同時作用於總數和數據
+	p, err = repo.List(ctx, page.Spec{}, func(db *gorm.DB) *gorm.DB {
+		return db.Where("age >= ?", 30)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), p.Total)
+	assert.Len(t, p.Data, 2)
+
+	// 未知的排序字段
+	_, err = repo.List(ctx, page.Spec{SortBy: "age; DROP TABLE test_accounts"})
+	assert.Equal(t, gormx.ErrUnknownSortByField, err)
+}
+
+func TestRepo_UpdatesWithFieldMask(t *testing.T) {
+	_, repo := setupTestRepo(t)
+	ctx := context.Background()
+
+	a := &testAccount{Name: "alice", Age: 20}
+	require.NoError(t, repo.Create(ctx, a))
+
+	// 不指定字段時忽略零值
+	require.NoError(t, repo.Updates(ctx, &testAccount{ID: a.ID, Name: "alice2"}))
+	found, _ := repo.FindByID(ctx, a.ID)
+	assert.Equal(t, "alice2", found.Name)
+	assert.Equal(t, 20, found.Age)
+
+	// 指定字段時只更新這些字段，包括零值
+	require.NoError(t, repo.Updates(ctx, &testAccount{ID: a.ID, Name: "ignored", Age: 0}, "age"))
+	found, _ = repo.FindByID(ctx, a.ID)
+	assert.Equal(t, "alice2", found.Name)
+	assert.Equal(t, 0, found.Age)
+}
+
+func TestRepo_DeleteAndExists(t *testing.T) {
+	_, repo := setupTestRepo(t)
+	ctx := context.Background()
+
+	a := &testAccount{Name: "alice"}
+	require.NoError(t, repo.Create(ctx, a))
+
+	exists, err := repo.Exists(ctx, "name = ?", "alice")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, repo.Delete(ctx, a.ID))
+
+	exists, err = repo.Exists(ctx, "name = ?", "alice")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// 沒有行被刪除
+	assert.ErrorIs(t, repo.Delete(ctx, a.ID), errorx.ErrNotFound)
+}
+
+type testArticle struct {
+	ID   string
+	Name string
+}
+
+func TestRepo_StringID(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&testArticle{}))
+	repo := NewRepo[testArticle](db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, &testArticle{ID: "a", Name: "doc-a"}))
+	require.NoError(t, repo.Create(ctx, &testArticle{ID: "b", Name: "doc-b"}))
+
+	found, err := repo.FindByID(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "doc-b", found.Name)
+
+	// 字符串 id 只作為參數綁定，不能注入 SQL
+	_, err = repo.FindByID(ctx, "1=1 OR id <> 'zzz'")
+	assert.ErrorIs(t, err, errorx.ErrNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, "id <> 'zzz'"), errorx.ErrNotFound)
+
+	exists, err := repo.Exists(ctx, "id IN ?", []string{"a", "b"})
+	require.NoError(t, err)
+	assert.True(t, exists)
+	var count int64
+	require.NoError(t, db.Model(&testArticle{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	require.NoError(t, repo.Delete(ctx, "a"))
+	_, err = repo.FindByID(ctx, "a")
+	assert.ErrorIs(t, err, errorx.ErrNotFound)
+}
+
+func TestRepo_IntegrationWithUow(t *testing.T) {
+	db, repo := setupTestRepo(t)
+	uow := NewGormUow(db)
+	ctx := context.Background()
+
+	testErr := errors.New("test error")
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		require.NoError(t, repo.Create(ctx, &testAccount{Name: "tx"}))
+
+		// 事務內可以讀到未提交的數據
+		exists, err := repo.Exists(ctx, "name = ?", "tx")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		return testErr
+	})
+	assert.ErrorIs(t, err, testErr)
+
+	exists, err := repo.Exists(ctx, "name = ?", "tx")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,100 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/irvingos/go-tools/gormx"
+	"github.com/irvingos/go-tools/logx"
+	"github.com/irvingos/go-tools/timex"
+)
+
+type RetryOptions struct {
+	// MaxAttempts 包含第一次执行在内的最大尝试次数
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Retryable 判断错误是否可以重试，默认重试序列化失败和死锁
+	Retryable func(err error) bool
+}
+
+func (o *RetryOptions) normalize() {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = 20 * time.Millisecond
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = time.Second
+	}
+	if o.Retryable == nil {
+		o.Retryable = gormx.IsRetryableTxError
+	}
+}
+
+// NewRetryUow 在 uow 外层包装重试，遇到可重试错误时以指数退避重新执行整个回调
+func NewRetryUow(uow Uow, o *RetryOptions) Uow {
+	var opts RetryOptions
+	if o != nil {
+		opts = *o
+	}
+	opts.normalize()
+
+	return &retryUow{
+		RetryOptions: opts,
+		uow:          uow,
+	}
+}
+
+type retryUow struct {
+	RetryOptions
+
+	uow Uow
+}
+
+// Do implements Uow.
+func (u *retryUow) Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	// 已经在事务中时，出错的是外层事务，只能由最外层重试
+	if _, ok := GormTxFrom(ctx); ok {
+		return u.uow.Do(ctx, fn, opts...)
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = u.uow.Do(ctx, fn, opts...)
+		if err == nil {
+			if attempt > 1 {
+				logx.WithContext(ctx).
+					WithField(logx.FieldAttempt, attempt).
+					Info("tx succeeded after retry")
+			}
+			return nil
+		}
+		if !u.Retryable(err) {
+			return err
+		}
+		if attempt >= u.MaxAttempts {
+			logx.WithContext(ctx).
+				WithField(logx.FieldAttempt, attempt).
+				WithError(err).
+				Error("tx retry exhausted")
+			return err
+		}
+
+		logx.WithContext(ctx).
+			WithField(logx.FieldAttempt, attempt).
+			WithError(err).
+			Warn("tx retry")
+
+		timer := time.NewTimer(timex.Backoff(attempt, u.BaseDelay, u.MaxDelay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			// 同时返回最后一次的错误，调用方可以判断取消前失败的原因
+			return errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package tx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/irvingos/go-tools/logx"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logx.Init(&logx.Options{})
+}
+
+func newRetryTestUow(t *testing.T) (Uow, func() int64) {
+	db := setupTestDBWithTable(t)
+	uow := NewRetryUow(NewGormUow(db), &RetryOptions{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	})
+	return uow, func() int64 { return countUsers(t, db, "retry") }
+}
+
+func TestRetryUow_Do_RetrySerializationFailure(t *testing.T) {
+	uow, count := newRetryTestUow(t)
+	ctx := context.Background()
+
+	attempts := 0
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		tx, _ := GormTxFrom(ctx)
+		assert.NoError(t, tx.Exec("INSERT INTO test_users (name) VALUES (?)", "retry").Error)
+
+		// 前兩次返回序列化失敗，事務回滾後重試
+		if attempts < 3 {
+			return &pgconn.PgError{Code: pgerrcode.SerializationFailure}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, int64(1), count())
+}
+
+func TestRetryUow_Do_MaxAttempts(t *testing.T) {
+	uow, count := newRetryTestUow(t)
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	logx.Init(&logx.Options{Format: logx.FormatJson, Output: buf})
+	t.Cleanup(func() { logx.Init(&logx.Options{}) })
+
+	attempts := 0
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		return &pgconn.PgError{Code: pgerrcode.DeadlockDetected}
+	})
+
+	var pgErr *pgconn.PgError
+	assert.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, pgerrcode.DeadlockDetected, pgErr.Code)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, int64(0), count())
+
+	// 重試用盡時記錄最後一次的錯誤
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var last map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &last))
+	assert.Equal(t, "tx retry exhausted", last["msg"])
+	assert.Equal(t, "error", last["level"])
+	assert.Equal(t, float64(3), last[logx.FieldAttempt])
+	assert.Contains(t, last[logx.FieldError], pgerrcode.DeadlockDetected)
+}
+
+func TestRetryUow_Do_NotRetryable(t *testing.T) {
+	uow, _ := newRetryTestUow(t)
+	ctx := context.Background()
+
+	testErr := errors.New("test error")
+
+	attempts := 0
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		return testErr
+	})
+	assert.ErrorIs(t, err, testErr)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryUow_Do_ContextCanceled(t *testing.T) {
+	uow, _ := newRetryTestUow(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attempts := 0
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		cancel()
+		return &pgconn.PgError{Code: pgerrcode.SerializationFailure}
+	})
+	// 取消時保留最後一次的錯誤
+	assert.ErrorIs(t, err, context.Canceled)
+	var pgErr *pgconn.PgError
+	assert.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, pgerrcode.SerializationFailure, pgErr.Code)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryUow_Do_InsideTransaction(t *testing.T) {
+	uow, _ := newRetryTestUow(t)
+	ctx := context.Background()
+
+	attempts := 0
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		// 內層不重試，錯誤交給最外層處理
+		return uow.Do(ctx, func(ctx context.Context) error {
+			attempts++
+			return &pgconn.PgError{Code: pgerrcode.SerializationFailure}
+		})
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 3, attempts)
+}
@@ -0,0 +1,232 @@
+package tx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/irvingos/go-tools/auth"
+	"github.com/irvingos/go-tools/errorx"
+	"github.com/irvingos/go-tools/gormx"
+	"github.com/irvingos/go-tools/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type testProject struct {
+	ID   int64
+	Name string
+	gormx.Tenant
+}
+
+func setupTenantDB(t *testing.T) *gorm.DB {
+	db := setupTestDB(t)
+	require.NoError(t, db.Use(gormx.TenantPlugin{}))
+	require.NoError(t, db.AutoMigrate(&testProject{}))
+	return db
+}
+
+func TestTenantPlugin_Repo(t *testing.T) {
+	db := setupTenantDB(t)
+	repo := NewRepo[testProject](db)
+	tenant1 := auth.WithTenantID(context.Background(), 1)
+	tenant2 := auth.WithTenantID(context.Background(), 2)
+
+	// 創建時自動寫入 tenant_id
+	p1 := &testProject{Name: "p1"}
+	require.NoError(t, repo.Create(tenant1, p1))
+	assert.Equal(t, 1, p1.TenantID)
+	require.NoError(t, repo.CreateInBatches(tenant2, []*testProject{{Name: "p2"}, {Name: "p3"}}, 10))
+
+	// 查詢只能看到本租戶的數據
+	ps, err := repo.FindBy(tenant2, "name <> ?", "")
+	require.NoError(t, err)
+	assert.Len(t, ps, 2)
+
+	_, err = repo.FindByID(tenant2, p1.ID)
+	assert.ErrorIs(t, err, errorx.ErrNotFound)
+
+	pg, err := repo.List(tenant1, page.Spec{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pg.Total)
+
+	// 更新和刪除不會影響其他租戶
+	require.NoError(t, repo.DBFrom(tenant2).Model(&testProject{}).Where("1 = 1").Update("name", "renamed").Error)
+	assert.ErrorIs(t, repo.Delete(tenant2, p1.ID), errorx.ErrNotFound)
+	found, err := repo.FindByID(tenant1, p1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "p1", found.Name)
+
+	// 不能寫入其他租戶的數據
+	assert.ErrorIs(t, repo.Create(tenant1, &testProject{Name: "p4", Tenant: gormx.Tenant{TenantID: 2}}), gormx.ErrTenantMismatch)
+}
+
+func TestTenantPlugin_MissingTenant(t *testing.T) {
+	db := setupTenantDB(t)
+	repo := NewRepo[testProject](db)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, repo.Create(ctx, &testProject{Name: "p1"}), gormx.ErrMissingTenant)
+	_, err := repo.FindBy(ctx, "name <> ?", "")
+	assert.ErrorIs(t, err, gormx.ErrMissingTenant)
+
+	// 不實現 Tenanted 的模型不受影響
+	require.NoError(t, db.AutoMigrate(&testUser{}))
+	assert.NoError(t, NewRepo[testUser](db).Create(ctx, &testUser{Name: "u1"}))
+}
+
+func TestTenantPlugin_WithoutTenant(t *testing.T) {
+	db := setupTenantDB(t)
+	repo := NewRepo[testProject](db)
+	require.NoError(t, repo.Create(auth.WithTenantID(context.Background(), 1), &testProject{Name: "p1"}))
+	require.NoError(t, repo.Create(auth.WithTenantID(context.Background(), 2), &testProject{Name: "p2"}))
+
+	ctx := gormx.WithoutTenant(context.Background())
+	ps, err := repo.FindBy(ctx, "name <> ?", "")
+	require.NoError(t, err)
+	assert.Len(t, ps, 2)
+
+	// 事務回調中同樣可以關閉租戶隔離或切換租戶
+	uow := NewGormUow(db)
+	err = uow.Do(auth.WithTenantID(context.Background(), 1), func(ctx context.Context) error {
+		ps, err := repo.FindBy(ctx, "1 = 1")
+		require.NoError(t, err)
+		assert.Len(t, ps, 1)
+
+		ps, err = repo.FindBy(gormx.WithoutTenant(ctx), "1 = 1")
+		require.NoError(t, err)
+		assert.Len(t, ps, 2)
+
+		ps, err = repo.FindBy(auth.WithTenantID(ctx, 2), "1 = 1")
+		require.NoError(t, err)
+		require.Len(t, ps, 1)
+		assert.Equal(t, "p2", ps[0].Name)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// 追加的 tenant_id 條件不會繞過 gorm 的無條件刪除保護
+	scoped := auth.WithTenantID(context.Background(), 1)
+	assert.ErrorIs(t, repo.DBFrom(scoped).Delete(&testProject{}).Error, gorm.ErrMissingWhereClause)
+}
+
+func TestTenantPlugin_Raw(t *testing.T) {
+	db := setupTenantDB(t)
+	repo := NewRepo[testProject](db)
+	tenant1 := auth.WithTenantID(context.Background(), 1)
+	require.NoError(t, repo.Create(tenant1, &testProject{Name: "p1"}))
+	require.NoError(t, repo.Create(auth.WithTenantID(context.Background(), 2), &testProject{Name: "p2"}))
+
+	// Raw 查詢無法追加租戶條件，結果為租戶模型時拒絕執行
+	var ps []testProject
+	err := repo.DBFrom(tenant1).Raw("SELECT * FROM test_projects").Find(&ps).Error
+	assert.ErrorIs(t, err, gormx.ErrRawTenantQuery)
+
+	// 自行限定租戶後通過 WithoutTenant 執行
+	err = repo.DBFrom(gormx.WithoutTenant(tenant1)).Raw("SELECT * FROM test_projects WHERE tenant_id = ?", 1).Find(&ps).Error
+	require.NoError(t, err)
+	require.Len(t, ps, 1)
+	assert.Equal(t, "p1", ps[0].Name)
+
+	// 結果不是租戶模型時不受影響
+	var count int64
+	require.NoError(t, repo.DBFrom(tenant1).Raw("SELECT COUNT(*) FROM test_projects").Scan(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestTenantPlugin_Uow(t *testing.T) {
+	db := setupTenantDB(t)
+	repo := NewRepo[testProject](db)
+	uow := NewGormUow(db)
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	auth.WithTenantID(c, 1)
+
+	// 帶超時的事務中 Statement.Context 從 Request.Context() 派生，只寫入 gin.Context 的租戶同樣生效
+	err := uow.Do(c, func(ctx context.Context) error {
+		if err := repo.Create(ctx, &testProject{Name: "p1"}); err != nil {
+			return err
+		}
+		exists, err := repo.Exists(ctx, "name = ?", "p1")
+		assert.True(t, exists)
+		return err
+	}, WithTimeout(time.Second))
+	require.NoError(t, err)
+
+	// gin.Context 中的值優先於 Request.Context()
+	c.Request = c.Request.WithContext(auth.WithTenantID(c.Request.Context(), 2))
+	err = uow.Do(c, func(ctx context.Context) error {
+		exists, err := repo.Exists(ctx, "name = ?", "p1")
+		assert.True(t, exists)
+		return err
+	}, WithTimeout(time.Second))
+	require.NoError(t, err)
+
+	ctx := auth.WithTenantID(context.Background(), 2)
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		exists, err := repo.Exists(ctx, "name = ?", "p1")
+		assert.False(t, exists)
+		return err
+	})
+	require.NoError(t, err)
+}
+
+type testSpace struct {
+	ID   int64
+	Name string
+	gormx.TenantOf[uuid.UUID]
+}
+
+type testFolder struct {
+	ID   int64
+	Name string
+	gormx.TenantOf[string]
+}
+
+func TestTenantPlugin_NonIntTenant(t *testing.T) {
+	db := setupTenantDB(t)
+	require.NoError(t, db.AutoMigrate(&testSpace{}, &testFolder{}))
+
+	// uuid.UUID 類型的租戶
+	spaces := NewRepo[testSpace](db)
+	tenantA, tenantB := uuid.New(), uuid.New()
+	ctxA := auth.WithTenantID(context.Background(), tenantA)
+	ctxB := auth.WithTenantID(context.Background(), tenantB)
+
+	s := &testSpace{Name: "s1"}
+	require.NoError(t, spaces.Create(ctxA, s))
+	assert.Equal(t, tenantA, s.TenantID)
+	found, err := spaces.FindByID(ctxA, s.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "s1", found.Name)
+	_, err = spaces.FindByID(ctxB, s.ID)
+	assert.ErrorIs(t, err, errorx.ErrNotFound)
+
+	// JWT 中間件默認寫入字符串形式的 UUID，同樣可以用於 uuid.UUID 的字段
+	_, err = spaces.FindByID(auth.WithTenantID(context.Background(), tenantA.String()), s.ID)
+	assert.NoError(t, err)
+	_, err = spaces.FindByID(auth.WithTenantID(context.Background(), "not-a-uuid"), s.ID)
+	assert.ErrorIs(t, err, gormx.ErrTenantMismatch)
+
+	// string 類型的租戶
+	folders := NewRepo[testFolder](db)
+	acme := auth.WithTenantID(context.Background(), "acme")
+	require.NoError(t, folders.Create(acme, &testFolder{Name: "f1"}))
+	require.NoError(t, folders.Create(auth.WithTenantID(context.Background(), "globex"), &testFolder{Name: "f2"}))
+	fs, err := folders.FindBy(acme, "name <> ?", "")
+	require.NoError(t, err)
+	require.Len(t, fs, 1)
+	assert.Equal(t, "acme", fs[0].TenantID)
+
+	assert.ErrorIs(t, folders.Create(acme, &testFolder{Name: "f3", TenantOf: gormx.TenantOf[string]{TenantID: "globex"}}), gormx.ErrTenantMismatch)
+	// 空字符串視為沒有租戶
+	_, err = folders.FindBy(auth.WithTenantID(context.Background(), ""), "name <> ?", "")
+	assert.ErrorIs(t, err, gormx.ErrMissingTenant)
+}
@@ -1,6 +1,7 @@
 package tx
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -23,3 +24,14 @@ func setupTestDBWithTable(t *testing.T) *gorm.DB {
 
 	return db
 }
+
+// setupTestFileDBWithTable 使用臨時文件數據庫，多個連接之間可以看到同一份數據
+func setupTestFileDBWithTable(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.Exec("CREATE TABLE test_users (id INTEGER PRIMARY KEY, name TEXT)").Error
+	require.NoError(t, err)
+
+	return db
+}
@@ -0,0 +1,99 @@
+package tx
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/irvingos/go-tools/auth"
+	"github.com/irvingos/go-tools/gormx"
+	"github.com/irvingos/go-tools/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracingPlugin(t *testing.T) {
+	e := trace.NewInMemoryExporter()
+	trace.SetExporter(e)
+	t.Cleanup(func() { trace.SetExporter(nil) })
+
+	db := setupTestDBWithTable(t)
+	require.NoError(t, db.Use(gormx.TracingPlugin{}))
+	repo := NewRepo[testUser](db)
+
+	// ctx 中沒有 span 時不創建
+	require.NoError(t, repo.Create(context.Background(), &testUser{Name: "u0"}))
+	assert.Empty(t, e.Spans())
+
+	ctx, root := trace.Start(context.Background(), "root")
+	require.NoError(t, repo.Create(ctx, &testUser{Name: "u1"}))
+	_, err := repo.FindByID(ctx, 100)
+	assert.Error(t, err)
+	assert.Error(t, repo.DBFrom(ctx).Exec("SELECT * FROM missing_table").Error)
+	root.End()
+
+	spans := e.Spans()
+	require.Len(t, spans, 4)
+	create, query, raw := spans[0], spans[1], spans[2]
+	for _, s := range spans[:3] {
+		assert.Equal(t, root.TraceID, s.TraceID)
+		assert.Equal(t, root.SpanID, s.ParentSpanID)
+		assert.Equal(t, trace.SpanKindClient, s.Kind)
+		assert.Equal(t, "sqlite", s.Attributes["db.system"])
+	}
+
+	assert.Equal(t, "gorm.create", create.Name)
+	assert.Contains(t, create.Attributes["db.statement"], "INSERT INTO `test_users`")
+	assert.Equal(t, "test_users", create.Attributes["db.sql.table"])
+	assert.Equal(t, int64(1), create.Attributes["db.rows_affected"])
+	// caller 與 db logger 一致，指向 gorm 之外的調用方
+	assert.True(t, strings.Contains(create.Attributes["code.caller"].(string), "tx/"))
+
+	// 查不到記錄不算失敗
+	assert.Equal(t, "gorm.query", query.Name)
+	assert.Equal(t, trace.StatusUnset, query.Status)
+
+	assert.Equal(t, "gorm.raw", raw.Name)
+	assert.Equal(t, trace.StatusError, raw.Status)
+	assert.Contains(t, raw.StatusMsg, "missing_table")
+}
+
+func TestTracingPlugin_GinContext(t *testing.T) {
+	e := trace.NewInMemoryExporter()
+	trace.SetExporter(e)
+	t.Cleanup(func() { trace.SetExporter(nil) })
+
+	db := setupTenantDB(t)
+	require.NoError(t, db.Use(gormx.TracingPlugin{}))
+	repo := NewRepo[testProject](db)
+	uow := NewGormUow(db)
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+	auth.WithTenantID(c, 1)
+	_, root := trace.Start(context.Background(), "root")
+	trace.WithSpan(c, root)
+
+	// 插件不替換 Statement.Context，租戶插件仍能讀取 gin.Context 中的租戶
+	require.NoError(t, repo.Create(c, &testProject{Name: "p1"}))
+
+	// 事務中創建的子 span 不會丟失 gin.Context 中的事務，語句歸屬於當前的子 span
+	var child *trace.Span
+	err := uow.Do(c, func(ctx context.Context) error {
+		ctx, child = trace.Start(ctx, "child")
+		defer child.End()
+		_, ok := GormTxFrom(ctx)
+		assert.True(t, ok)
+		return repo.Create(ctx, &testProject{Name: "p2"})
+	})
+	require.NoError(t, err)
+	root.End()
+
+	spans := e.Spans()
+	require.Len(t, spans, 4)
+	assert.Equal(t, root.SpanID, spans[0].ParentSpanID)
+	assert.Equal(t, "gorm.create", spans[1].Name)
+	assert.Equal(t, child.SpanID, spans[1].ParentSpanID)
+	assert.Equal(t, root.SpanID, child.ParentSpanID)
+}
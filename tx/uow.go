@@ -3,5 +3,5 @@ package tx
 import "context"
 
 type Uow interface {
-	Do(ctx context.Context, fn func(ctx context.Context) error) error
+	Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error
 }